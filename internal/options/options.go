@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/pflag"
 	"go.uber.org/zap/zapcore"
@@ -11,12 +12,47 @@ import (
 )
 
 type Options struct {
-	MetricsPort    int32
-	MetricsPath    string
-	ProbeAddr      string
-	ConfigFile     string
+	MetricsPort int32
+	MetricsPath string
+	ProbeAddr   string
+	ConfigFile  string
+	// FailureInfoMaxSeries caps the number of series of the failure info metric,
+	// which is disabled when it is 0
+	FailureInfoMaxSeries int
+	// OTLPEndpoint is the base URL of the OTLP/HTTP collector the results
+	// are pushed to, the exporter being disabled when it is empty
+	OTLPEndpoint string
+	OTLPHeaders  map[string]string
+	OTLPInterval time.Duration
+	// NotifyWebhookURL is the webhook notified of the objects whose validation
+	// outcome changed, the notifications being disabled when it is empty
+	NotifyWebhookURL    string
+	NotifyFormat        string
+	NotifyTemplateFile  string
+	NotifyBatchInterval time.Duration
+	NotifyMaxPerMinute  int
+	// HistoryFile is the path of the database recording the daily summaries,
+	// the history being disabled when it is empty
+	HistoryFile      string
+	HistoryRetention time.Duration
+	// ArgoCDNamespace is the namespace Argo CD is installed in, where the Applications
+	// not telling their namespace are looked up
+	ArgoCDNamespace string
+	// ReportFormat is the format of the report of the manifests in ReportInput.
+	// When it is set, the report is written to ReportOutput instead of running the operator.
+	ReportFormat   string
+	ReportInput    []string
+	ReportOutput   string
 	watchNamespace *string
 	Zap            zap.Options
+
+	// AutoRemediationChecks are the checks whose failures are patched in the
+	// namespaces opted in, the automatic remediation being disabled when it is empty
+	AutoRemediationChecks            []string
+	AutoRemediationNamespaceSelector string
+	AutoRemediationDefaults          string
+	AutoRemediationDryRun            bool
+	AutoRemediationAuditLog          string
 }
 
 func (o *Options) MetricsEndpoint() string {
@@ -58,6 +94,107 @@ func (o *Options) processFlags() {
 		"health-probe-bind-address", o.ProbeAddr,
 		"The address the probe endpoint binds to.",
 	)
+	flags.IntVar(
+		&o.FailureInfoMaxSeries,
+		"failure-info-max-series", o.FailureInfoMaxSeries,
+		"Maximum number of series of the dvo_check_failure_info metric. 0 disables the metric.",
+	)
+	flags.StringVar(
+		&o.OTLPEndpoint,
+		"otlp-endpoint", o.OTLPEndpoint,
+		"Base URL of the OTLP/HTTP collector receiving the results, e.g. http://collector:4318.",
+	)
+	flags.StringToStringVar(
+		&o.OTLPHeaders,
+		"otlp-headers", o.OTLPHeaders,
+		"Headers sent to the OTLP collector, e.g. Authorization=Bearer <token>.",
+	)
+	flags.DurationVar(
+		&o.OTLPInterval,
+		"otlp-interval", o.OTLPInterval,
+		"Interval between two exports of the metrics to the OTLP collector.",
+	)
+	flags.StringVar(
+		&o.NotifyWebhookURL,
+		"notify-webhook-url", o.NotifyWebhookURL,
+		"Webhook notified of the objects newly failing validation or newly fixed.",
+	)
+	flags.StringVar(
+		&o.NotifyFormat,
+		"notify-format", o.NotifyFormat,
+		"Format of the notifications: generic, slack or teams.",
+	)
+	flags.StringVar(
+		&o.NotifyTemplateFile,
+		"notify-template", o.NotifyTemplateFile,
+		"Path to a Go template rendering the notifications, overriding the format.",
+	)
+	flags.DurationVar(
+		&o.NotifyBatchInterval,
+		"notify-batch-interval", o.NotifyBatchInterval,
+		"Period the changes are accumulated for before being notified at once.",
+	)
+	flags.IntVar(
+		&o.NotifyMaxPerMinute,
+		"notify-max-per-minute", o.NotifyMaxPerMinute,
+		"Maximum number of notifications sent per minute.",
+	)
+	flags.StringVar(
+		&o.HistoryFile,
+		"history-file", o.HistoryFile,
+		"Path of the database recording a daily summary of each namespace, e.g. on a persistent volume.",
+	)
+	flags.DurationVar(
+		&o.HistoryRetention,
+		"history-retention", o.HistoryRetention,
+		"Period the daily summaries are kept for.",
+	)
+	flags.StringVar(
+		&o.ArgoCDNamespace,
+		"argocd-namespace", o.ArgoCDNamespace,
+		"Namespace Argo CD is installed in, e.g. openshift-gitops for OpenShift GitOps.",
+	)
+	flags.StringVar(
+		&o.ReportFormat,
+		"report", o.ReportFormat,
+		"Write a report of the manifests in --report-input in the given format "+
+			"(html, markdown, csv, sarif or junit) and exit.",
+	)
+	flags.StringSliceVar(
+		&o.ReportInput,
+		"report-input", o.ReportInput,
+		"Manifest files or directories validated by --report.",
+	)
+	flags.StringVar(
+		&o.ReportOutput,
+		"report-output", o.ReportOutput,
+		"Path of the file the report is written to, the standard output by default.",
+	)
+	flags.StringSliceVar(
+		&o.AutoRemediationChecks,
+		"auto-remediation-checks", o.AutoRemediationChecks,
+		"Checks whose failures are patched automatically in the namespaces opted in.",
+	)
+	flags.StringVar(
+		&o.AutoRemediationNamespaceSelector,
+		"auto-remediation-namespace-selector", o.AutoRemediationNamespaceSelector,
+		"Label selector of the namespaces opted in to the automatic remediation.",
+	)
+	flags.StringVar(
+		&o.AutoRemediationDefaults,
+		"auto-remediation-defaults", o.AutoRemediationDefaults,
+		"Path to a YAML file defining the defaultRequest and default resources set on the containers.",
+	)
+	flags.BoolVar(
+		&o.AutoRemediationDryRun,
+		"auto-remediation-dry-run", o.AutoRemediationDryRun,
+		"Only validate the patches with a server-side dry-run, without applying them.",
+	)
+	flags.StringVar(
+		&o.AutoRemediationAuditLog,
+		"auto-remediation-audit-log", o.AutoRemediationAuditLog,
+		"Path of the file the patches applied are appended to, as JSON lines.",
+	)
 
 	pflag.CommandLine.AddFlagSet(flags)
 
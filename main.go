@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -17,7 +19,12 @@ import (
 	"github.com/app-sre/deployment-validation-operator/internal/options"
 	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
 	"github.com/app-sre/deployment-validation-operator/pkg/controller"
+	"github.com/app-sre/deployment-validation-operator/pkg/history"
+	"github.com/app-sre/deployment-validation-operator/pkg/notify"
+	"github.com/app-sre/deployment-validation-operator/pkg/otlp"
 	dvoProm "github.com/app-sre/deployment-validation-operator/pkg/prometheus"
+	"github.com/app-sre/deployment-validation-operator/pkg/remediation"
+	"github.com/app-sre/deployment-validation-operator/pkg/report"
 	"github.com/app-sre/deployment-validation-operator/pkg/validations"
 	"github.com/app-sre/deployment-validation-operator/version"
 	"github.com/prometheus/client_golang/prometheus"
@@ -37,17 +44,38 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 )
 
-const operatorNameEnvVar = "OPERATOR_NAME"
+const (
+	operatorNameEnvVar     = "OPERATOR_NAME"
+	stagedConfigReportPath = "/staged-config/report"
+	effectiveConfigPath    = "/config"
+	resultsPath            = "/results"
+	historyPath            = "/history"
+	reportPath             = "/report"
+	remediationsPath       = "/remediations"
+	helmReleasesPath       = "/helm-releases"
+	// defaultHistoryRetention keeps the summaries of more than a year
+	defaultHistoryRetention = 400 * 24 * time.Hour
+	// defaultAutoRemediationSelector is the label opting a namespace in to the automatic remediation
+	defaultAutoRemediationSelector = "deployment-validation-operator.openshift.io/auto-remediation=true"
+)
 
 func main() {
 	// Make sure the operator name is what we want
 	os.Setenv(operatorNameEnvVar, dvconfig.OperatorName)
 
 	opts := options.Options{
-		MetricsPort: 8383,
-		MetricsPath: "metrics",
-		ProbeAddr:   ":8081",
-		ConfigFile:  "config/deployment-validation-operator-config.yaml",
+		MetricsPort:         8383,
+		MetricsPath:         "metrics",
+		ProbeAddr:           ":8081",
+		ConfigFile:          "config/deployment-validation-operator-config.yaml",
+		OTLPInterval:        30 * time.Second,
+		NotifyFormat:        notify.FormatGeneric,
+		NotifyBatchInterval: time.Minute,
+		NotifyMaxPerMinute:  6,
+		HistoryRetention:    defaultHistoryRetention,
+		ArgoCDNamespace:     validations.DefaultArgoCDNamespace,
+
+		AutoRemediationNamespaceSelector: defaultAutoRemediationSelector,
 	}
 
 	opts.Process()
@@ -63,6 +91,14 @@ func main() {
 	logf.SetLogger(zap.New(zap.UseFlagOptions(&opts.Zap)))
 
 	log := logf.Log.WithName("DeploymentValidation")
+
+	if opts.ReportFormat != "" {
+		if err := writeReport(opts); err != nil {
+			fail(log, err, "Unexpected error occurred while writing the report")
+		}
+		return
+	}
+
 	logVersion(log)
 
 	log.Info("Setting Up Manager")
@@ -100,10 +136,6 @@ func setupManager(logger logr.Logger, opts options.Options) (manager.Manager, er
 	logger.Info("Initialize Prometheus Registry")
 
 	reg := prometheus.NewRegistry()
-	metrics, err := dvoProm.PreloadMetrics(reg)
-	if err != nil {
-		return nil, fmt.Errorf("preloading kube-linter metrics: %w", err)
-	}
 
 	logger.Info("Initialize Prometheus metrics endpoint", "endpoint", opts.MetricsEndpoint())
 
@@ -118,7 +150,7 @@ func setupManager(logger logr.Logger, opts options.Options) (manager.Manager, er
 
 	logger.Info("Initialize ConfigMap watcher")
 
-	cmWatcher, err := configmap.NewWatcher(cfg)
+	cmWatcher, err := configmap.NewWatcher(cfg, opts.ConfigFile)
 	if err != nil {
 		return nil, fmt.Errorf("initializing configmap watcher: %w", err)
 	}
@@ -127,12 +159,73 @@ func setupManager(logger logr.Logger, opts options.Options) (manager.Manager, er
 		return nil, fmt.Errorf("adding configmap watcher to manager: %w", err)
 	}
 
+	logger.Info("Initialize effective configuration endpoint", "path", effectiveConfigPath)
+
+	srv.Handle(effectiveConfigPath, cmWatcher.EffectiveConfigHandler())
+
+	if err := reg.Register(cmWatcher.ActiveGenerationMetric()); err != nil {
+		return nil, fmt.Errorf("registering configuration generation metric: %w", err)
+	}
+
+	// the labels of the metrics are defined once, from the initial configuration
+	snapshot := cmWatcher.CurrentConfig()
+	metrics, err := dvoProm.PreloadMetrics(reg, snapshot.MetricLabels())
+	if err != nil {
+		return nil, fmt.Errorf("preloading kube-linter metrics: %w", err)
+	}
+
 	logger.Info("Initialize Validation Engine")
 
-	validationEngine, err := validations.NewValidationEngine(opts.ConfigFile, metrics)
+	validationEngine, err := newValidationEngine(snapshot, metrics)
 	if err != nil {
 		return nil, fmt.Errorf("initializing validation engine: %w", err)
 	}
+	cmWatcher.MarkActive(snapshot)
+
+	if opts.FailureInfoMaxSeries > 0 {
+		logger.Info("Initialize failure info metric", "maxSeries", opts.FailureInfoMaxSeries)
+
+		failureInfo := validations.NewFailureInfoMetric(opts.FailureInfoMaxSeries, snapshot.MetricLabels())
+		if err := reg.Register(failureInfo); err != nil {
+			return nil, fmt.Errorf("registering failure info metric: %w", err)
+		}
+		validationEngine.SetFailureInfoMetric(failureInfo)
+	}
+
+	logger.Info("Initialize results endpoint", "path", resultsPath)
+
+	failureTracker := validations.NewFailureTracker(snapshot.MetricLabels())
+	if err := reg.Register(failureTracker); err != nil {
+		return nil, fmt.Errorf("registering failing since metric: %w", err)
+	}
+	validationEngine.SetFailureTracker(failureTracker)
+	srv.Handle(resultsPath, failureTracker.ResultsHandler())
+
+	logger.Info("Initialize report endpoint", "path", reportPath)
+
+	srv.Handle(reportPath, report.Handler(validationEngine))
+
+	logger.Info("Initialize remediations endpoint", "path", remediationsPath)
+
+	srv.Handle(remediationsPath, remediation.Handler(validationEngine))
+
+	if opts.OTLPEndpoint != "" {
+		logger.Info("Initialize OTLP exporter", "endpoint", opts.OTLPEndpoint)
+
+		exporter, err := otlp.NewExporter(context.Background(), reg, otlp.Options{
+			EndpointURL: opts.OTLPEndpoint,
+			Headers:     opts.OTLPHeaders,
+			Interval:    opts.OTLPInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("initializing OTLP exporter: %w", err)
+		}
+
+		if err := mgr.Add(exporter); err != nil {
+			return nil, fmt.Errorf("adding OTLP exporter to manager: %w", err)
+		}
+		validationEngine.SetFailureReporter(exporter)
+	}
 
 	logger.Info("Initialize Reconciler")
 
@@ -146,13 +239,186 @@ func setupManager(logger logr.Logger, opts options.Options) (manager.Manager, er
 		return nil, fmt.Errorf("initializing generic reconciler: %w", err)
 	}
 
+	if ns, _ := opts.GetWatchNamespace(); ns != "" {
+		gr.SetWatchNamespaces(splitWatchNamespace(ns))
+	}
+	gr.SetArgoCDNamespace(opts.ArgoCDNamespace)
+
+	if opts.NotifyWebhookURL != "" {
+		logger.Info("Initialize notifier")
+
+		notifier, err := newNotifier(opts)
+		if err != nil {
+			return nil, fmt.Errorf("initializing notifier: %w", err)
+		}
+
+		if err := mgr.Add(notifier); err != nil {
+			return nil, fmt.Errorf("adding notifier to manager: %w", err)
+		}
+		gr.SetNotifier(notifier)
+	}
+
+	if opts.HistoryFile != "" {
+		logger.Info("Initialize history endpoint", "path", historyPath, "file", opts.HistoryFile)
+
+		store, err := history.Open(opts.HistoryFile, opts.HistoryRetention)
+		if err != nil {
+			return nil, fmt.Errorf("initializing history store: %w", err)
+		}
+
+		if err := mgr.Add(store); err != nil {
+			return nil, fmt.Errorf("adding history store to manager: %w", err)
+		}
+
+		failingSince, err := store.FailingSince()
+		if err != nil {
+			return nil, fmt.Errorf("reading failing since times: %w", err)
+		}
+		failureTracker.Seed(failingSince)
+
+		gr.SetHistory(store)
+		srv.Handle(historyPath, store.Handler())
+	}
+
+	if len(opts.AutoRemediationChecks) > 0 {
+		logger.Info("Initialize automatic remediation", "checks", opts.AutoRemediationChecks,
+			"namespaceSelector", opts.AutoRemediationNamespaceSelector,
+			"dryRun", opts.AutoRemediationDryRun)
+
+		remediator, err := newAutoRemediator(mgr.GetClient(), logger, opts)
+		if err != nil {
+			return nil, fmt.Errorf("initializing automatic remediation: %w", err)
+		}
+		gr.SetRemediator(remediator)
+	}
+
 	if err = gr.AddToManager(mgr); err != nil {
 		return nil, fmt.Errorf("adding generic reconciler to manager: %w", err)
 	}
 
+	logger.Info("Initialize staged configuration report endpoint", "path", stagedConfigReportPath)
+
+	srv.Handle(stagedConfigReportPath, gr.StagedConfigReportHandler())
+
+	logger.Info("Initialize Helm releases endpoint", "path", helmReleasesPath)
+
+	srv.Handle(helmReleasesPath, gr.HelmReleasesHandler())
+
 	return mgr, nil
 }
 
+func newNotifier(opts options.Options) (*notify.Notifier, error) {
+	var tmpl string
+	if opts.NotifyTemplateFile != "" {
+		data, err := os.ReadFile(opts.NotifyTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading notification template: %w", err)
+		}
+		tmpl = string(data)
+	}
+
+	return notify.NewNotifier(notify.Options{
+		WebhookURL:    opts.NotifyWebhookURL,
+		Format:        opts.NotifyFormat,
+		Template:      tmpl,
+		BatchInterval: opts.NotifyBatchInterval,
+		MaxPerMinute:  opts.NotifyMaxPerMinute,
+	})
+}
+
+func newAutoRemediator(c client.Client, logger logr.Logger,
+	opts options.Options) (*remediation.AutoRemediator, error) {
+	var defaults remediation.Defaults
+	if opts.AutoRemediationDefaults != "" {
+		var err error
+		defaults, err = remediation.LoadDefaults(opts.AutoRemediationDefaults)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return remediation.NewAutoRemediator(c, logger.WithName("remediation"), remediation.AutoOptions{
+		Checks:            opts.AutoRemediationChecks,
+		NamespaceSelector: opts.AutoRemediationNamespaceSelector,
+		Defaults:          defaults,
+		DryRun:            opts.AutoRemediationDryRun,
+		AuditLog:          opts.AutoRemediationAuditLog,
+	})
+}
+
+// writeReport validates the manifests given as input, without any cluster,
+// and writes the report of the failures found
+func writeReport(opts options.Options) error {
+	if len(opts.ReportInput) == 0 {
+		return errors.New("no manifests to report on, see --report-input")
+	}
+
+	scheme, err := initializeScheme()
+	if err != nil {
+		return fmt.Errorf("initializing scheme: %w", err)
+	}
+
+	objects, err := report.LoadManifests(scheme, opts.ReportInput)
+	if err != nil {
+		return fmt.Errorf("loading manifests: %w", err)
+	}
+
+	// the manifests are validated with the configuration file, as the operator does
+	// when there is no ConfigMap in the cluster
+	snapshot, err := configmap.FileConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("loading configuration: %w", err)
+	}
+	engine, err := newValidationEngine(snapshot, nil)
+	if err != nil {
+		return fmt.Errorf("initializing validation engine: %w", err)
+	}
+	engine.SetSourceResolver(validations.TrackedSources{ArgoCDNamespace: opts.ArgoCDNamespace})
+
+	var results []validations.CheckResult
+	namespaces := report.ManifestNamespaces(objects)
+	for _, group := range report.GroupByNamespace(objects) {
+		namespace := namespaces[group.Namespace]
+		failures, err := engine.EvaluateObjects(context.Background(), group.Objects, namespace)
+		if err != nil {
+			return fmt.Errorf("validating manifests: %w", err)
+		}
+		for _, f := range failures {
+			results = append(results, validations.CheckResult{Failure: f})
+		}
+	}
+
+	out := os.Stdout
+	if opts.ReportOutput != "" {
+		out, err = os.Create(opts.ReportOutput)
+		if err != nil {
+			return fmt.Errorf("creating report file: %w", err)
+		}
+		defer out.Close()
+	}
+
+	return report.New(results, engine.GetCheck).Render(out, opts.ReportFormat)
+}
+
+// newValidationEngine returns a validation engine applying the given configuration
+func newValidationEngine(snapshot configmap.Snapshot,
+	metrics map[string]*prometheus.GaugeVec) (validations.Interface, error) {
+	engine, err := validations.NewValidationEngineFromConfig(snapshot.Config(), metrics)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetMetricLabels(snapshot.MetricLabels())
+	engine.SetReplicas(snapshot.Replicas())
+	engine.SetImages(snapshot.Images())
+	if err := engine.InitRegistry(); err != nil {
+		return nil, err
+	}
+	if err := engine.SetOwnership(snapshot.Ownership()); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
 func fail(logger logr.Logger, err error, msg string) {
 	logger.Error(err, msg)
 
@@ -208,7 +474,7 @@ func getManagerOptions(scheme *k8sruntime.Scheme, opts options.Options, logger l
 	// More: https://godoc.org/github.com/kubernetes-sigs/controller-runtime/pkg/cache#MultiNamespacedCacheBuilder
 	if ns != "" {
 		defaultNamespaces := make(map[string]cache.Config)
-		for _, namespace := range strings.Split(ns, ",") {
+		for _, namespace := range splitWatchNamespace(ns) {
 			defaultNamespaces[namespace] = cache.Config{}
 		}
 		mgrOpts.Cache.DefaultNamespaces = defaultNamespaces
@@ -217,6 +483,17 @@ func getManagerOptions(scheme *k8sruntime.Scheme, opts options.Options, logger l
 	return mgrOpts, nil
 }
 
+// splitWatchNamespace returns the namespaces of the comma separated WATCH_NAMESPACE value
+func splitWatchNamespace(ns string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(ns, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
 func newClient(cfg *rest.Config, opts client.Options) (client.Client, error) {
 	qps, err := kubeClientQPS()
 	if err != nil {
@@ -2,16 +2,23 @@ package configmap
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	dvoConfig "github.com/app-sre/deployment-validation-operator/config"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
 	"golang.stackrox.io/kube-linter/pkg/config"
 
-	"github.com/app-sre/deployment-validation-operator/pkg/validations"
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	apicorev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -20,26 +27,51 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// Watcher merges the configuration layers and publishes every change
+// as a new Snapshot. All its fields are guarded by mux.
 type Watcher struct {
 	clientset kubernetes.Interface
-	cfg       config.Config
-	ch        chan struct{}
+	mux       sync.RWMutex
+	snapshot  Snapshot
+	ch        chan Snapshot
+	stagedCh  chan struct{}
 	logger    logr.Logger
 	namespace string
+
+	// snapshot currently applied by the consumer, and its generation
+	active           Snapshot
+	activeGeneration prometheus.Gauge
+
+	// configuration layers, see Layer for their precedence
+	configFile   string
+	fileLayer    *Layer
+	clusterLayer *Layer
+	stagedLayer  *Layer
+	extraLayers  map[string]Layer
 }
 
 var configMapName = "deployment-validation-operator-config"
 var configMapDataAccess = "deployment-validation-operator-config.yaml"
 
+// configMapStagedDataAccess is the ConfigMap key holding a candidate configuration.
+// It is only previewed against the validated objects and never applied until
+// its content is promoted to the configMapDataAccess key.
+var configMapStagedDataAccess = "deployment-validation-operator-staged-config.yaml"
+
+// configLayerLabel marks additional ConfigMaps, in the operator namespace, whose
+// configMapDataAccess key is merged on top of the cluster ConfigMap
+var configLayerLabel = "dvo.openshift.io/config-layer"
+
 // NewWatcher creates a new Watcher instance for observing changes to a ConfigMap.
 //
 // Parameters:
 //   - cfg: A pointer to a rest.Config representing the Kubernetes client configuration.
+//   - configFile: The path to the configuration file, ignored if it does not exist.
 //
 // Returns:
-//   - A pointer to a Watcher instance for monitoring changes to DVO ConfigMap resource.
-//   - An error if there's an issue while initializing the Kubernetes clientset.
-func NewWatcher(cfg *rest.Config) (*Watcher, error) {
+//   - A pointer to a Watcher instance for monitoring changes to DVO ConfigMap resources.
+//   - An error if there's an issue while initializing the Kubernetes clientset or reading the file.
+func NewWatcher(cfg *rest.Config, configFile string) (*Watcher, error) {
 	clientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("initializing clientset: %w", err)
@@ -51,12 +83,41 @@ func NewWatcher(cfg *rest.Config) (*Watcher, error) {
 		return nil, fmt.Errorf("getting namespace: %w", err)
 	}
 
+	cmw := newWatcher(clientset, namespace, configFile)
+
+	fileLayer, ok, err := newFileLayer(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cmw.fileLayer = &fileLayer
+	}
+	cmw.snapshot.cfg = MergeLayers(cmw.layers())
+	cmw.snapshot.metricLabels = MergeMetricLabels(cmw.layers())
+	cmw.snapshot.namespaces = MergeNamespaces(cmw.layers())
+	cmw.snapshot.replicas = MergeReplicas(cmw.layers())
+	cmw.snapshot.ownership = MergeOwnership(cmw.layers())
+	cmw.snapshot.grouping = MergeGrouping(cmw.layers())
+	cmw.snapshot.images = MergeImages(cmw.layers())
+
+	return cmw, nil
+}
+
+func newWatcher(clientset kubernetes.Interface, namespace, configFile string) *Watcher {
 	return &Watcher{
 		clientset: clientset,
 		logger:    log.Log.WithName("ConfigMapWatcher"),
-		ch:        make(chan struct{}),
-		namespace: namespace,
-	}, nil
+		// a single pending snapshot is kept, as only the latest one matters
+		ch:          make(chan Snapshot, 1),
+		stagedCh:    make(chan struct{}, 1),
+		namespace:   namespace,
+		configFile:  configFile,
+		extraLayers: map[string]Layer{},
+		activeGeneration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: strings.ReplaceAll(dvoConfig.OperatorName+"_config_generation", "-", "_"),
+			Help: "Generation of the configuration snapshot currently applied by the validation engine",
+		}),
+	}
 }
 
 // Start will update the channel structure with new configuration data from ConfigMap update event
@@ -70,7 +131,7 @@ func (cmw *Watcher) Start(ctx context.Context) error {
 		AddFunc: func(obj interface{}) {
 			newCm := obj.(*apicorev1.ConfigMap)
 
-			if configMapName != newCm.GetName() {
+			if configMapName != newCm.GetName() && !isConfigLayer(newCm) {
 				return
 			}
 
@@ -80,21 +141,22 @@ func (cmw *Watcher) Start(ctx context.Context) error {
 				"namespace", newCm.GetNamespace(),
 			)
 
-			cfg, err := readConfig(newCm.Data[configMapDataAccess])
-			if err != nil {
-				cmw.logger.Error(err, "ConfigMap data format")
-				return
-			}
+			cmw.setLayer(newCm)
 
-			cmw.cfg = cfg
-
-			cmw.ch <- struct{}{}
+			if _, ok := newCm.Data[configMapStagedDataAccess]; ok && configMapName == newCm.GetName() {
+				cmw.publishStagedConfig(newCm)
+			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCm := oldObj.(*apicorev1.ConfigMap)
 			newCm := newObj.(*apicorev1.ConfigMap)
 
 			// This is sometimes triggered even if no change was due to the ConfigMap
-			if configMapName != newCm.GetName() || reflect.DeepEqual(oldObj, newObj) {
+			if reflect.DeepEqual(oldObj, newObj) {
+				return
+			}
+
+			if configMapName != newCm.GetName() && !isConfigLayer(oldCm) && !isConfigLayer(newCm) {
 				return
 			}
 
@@ -104,18 +166,39 @@ func (cmw *Watcher) Start(ctx context.Context) error {
 				"namespace", newCm.GetNamespace(),
 			)
 
-			cfg, err := readConfig(newCm.Data[configMapDataAccess])
-			if err != nil {
-				cmw.logger.Error(err, "ConfigMap data format")
+			if configMapName == newCm.GetName() && stagedDataChanged(oldCm, newCm) {
+				cmw.publishStagedConfig(newCm)
+			}
+
+			if configMapName != newCm.GetName() && !isConfigLayer(newCm) {
+				// the ConfigMap is no longer labelled as a configuration layer
+				cmw.removeLayer(newCm)
 				return
 			}
 
-			cmw.cfg = cfg
+			// changes limited to the staged configuration must not reload the active one
+			if isConfigLayer(oldCm) == isConfigLayer(newCm) &&
+				oldCm.Data[configMapDataAccess] == newCm.Data[configMapDataAccess] {
+				return
+			}
 
-			cmw.ch <- struct{}{}
+			cmw.setLayer(newCm)
 		},
 		DeleteFunc: func(oldObj interface{}) {
-			cm := oldObj.(*apicorev1.ConfigMap)
+			cm, ok := oldObj.(*apicorev1.ConfigMap)
+			if !ok {
+				tombstone, isTombstone := oldObj.(cache.DeletedFinalStateUnknown)
+				if !isTombstone {
+					return
+				}
+				if cm, ok = tombstone.Obj.(*apicorev1.ConfigMap); !ok {
+					return
+				}
+			}
+
+			if configMapName != cm.GetName() && !isConfigLayer(cm) {
+				return
+			}
 
 			cmw.logger.Info(
 				"a ConfigMap has been deleted under watched namespace",
@@ -123,40 +206,315 @@ func (cmw *Watcher) Start(ctx context.Context) error {
 				"namespace", cm.GetNamespace(),
 			)
 
-			cmw.cfg = config.Config{
-				Checks: validations.GetDefaultChecks(),
-			}
+			cmw.removeLayer(cm)
 
-			cmw.ch <- struct{}{}
+			if configMapName == cm.GetName() && cmw.hasStagedConfig() {
+				cmw.setStagedLayer(nil)
+			}
 		},
 	})
 
 	factory.Start(ctx.Done())
 
+	if cmw.configFile != "" {
+		go func() {
+			if err := cmw.watchConfigFile(ctx); err != nil {
+				cmw.logger.Error(err, "watching configuration file", "path", cmw.configFile)
+			}
+		}()
+	}
+
 	return nil
 }
 
-// ConfigChanged receives push notifications when the configuration is updated
-func (cmw *Watcher) ConfigChanged() <-chan struct{} {
+// ConfigChanged receives the new Snapshot when the configuration is updated.
+// Snapshots published while a previous one has not been received yet replace it,
+// so a slow consumer only gets the latest configuration.
+func (cmw *Watcher) ConfigChanged() <-chan Snapshot {
 	return cmw.ch
 }
 
-// GetConfig returns a previously saved kube-linter Config structure
-func (cmw *Watcher) GetConfig() config.Config {
-	return cmw.cfg
+// CurrentConfig returns the latest Snapshot of the merged configuration
+func (cmw *Watcher) CurrentConfig() Snapshot {
+	cmw.mux.RLock()
+	defer cmw.mux.RUnlock()
+
+	return cmw.snapshot
+}
+
+// MarkActive records the given Snapshot as the one applied by the validation engine
+func (cmw *Watcher) MarkActive(s Snapshot) {
+	cmw.mux.Lock()
+	defer cmw.mux.Unlock()
+
+	cmw.active = s
+	if cmw.activeGeneration == nil {
+		return
+	}
+	cmw.activeGeneration.Set(float64(s.Generation()))
+}
+
+// ActiveConfig returns the Snapshot last marked as active, see MarkActive
+func (cmw *Watcher) ActiveConfig() Snapshot {
+	cmw.mux.RLock()
+	defer cmw.mux.RUnlock()
+
+	return cmw.active
+}
+
+// ActiveGenerationMetric returns the gauge exposing the generation
+// of the Snapshot marked as active
+func (cmw *Watcher) ActiveGenerationMetric() prometheus.Collector {
+	return cmw.activeGeneration
+}
+
+// publish merges the layers into a new Snapshot and sends it without blocking,
+// replacing any Snapshot still pending. The caller must hold the lock.
+func (cmw *Watcher) publish() {
+	generation := cmw.snapshot.generation + 1
+	cmw.snapshot = mergeSnapshot(cmw.layers())
+	cmw.snapshot.generation = generation
+	cmw.snapshot.resourceVersion = cmw.clusterResourceVersion()
+
+	if cmw.ch == nil {
+		return
+	}
+
+	// the lock guarantees there is a single sender, so after draining
+	// the pending Snapshot, if any, the send cannot block
+	select {
+	case <-cmw.ch:
+	default:
+	}
+	cmw.ch <- cmw.snapshot
+}
+
+// clusterResourceVersion returns the resourceVersion of the cluster ConfigMap, if any.
+// The caller must hold the lock.
+func (cmw *Watcher) clusterResourceVersion() string {
+	if cmw.clusterLayer == nil {
+		return ""
+	}
+	return cmw.clusterLayer.resourceVersion
+}
+
+// StagedConfigChanged receives push notifications when the staged configuration
+// is created, updated or removed
+func (cmw *Watcher) StagedConfigChanged() <-chan struct{} {
+	return cmw.stagedCh
+}
+
+// StagedConfig returns the Snapshot that would be in effect if the staged configuration
+// replaced the one of the cluster ConfigMap, and whether a staged configuration is
+// currently defined. Its generation and resourceVersion are not set.
+func (cmw *Watcher) StagedConfig() (Snapshot, bool) {
+	cmw.mux.RLock()
+	defer cmw.mux.RUnlock()
+
+	if cmw.stagedLayer == nil {
+		return Snapshot{}, false
+	}
+
+	layers := cmw.baseLayers()
+	layers = append(layers, *cmw.stagedLayer)
+	layers = append(layers, cmw.sortedExtraLayers()...)
+
+	return mergeSnapshot(layers), true
+}
+
+// EffectiveConfigHandler returns an http.Handler serving, as JSON, the
+// merged configuration together with the layers it has been built from
+func (cmw *Watcher) EffectiveConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		cmw.mux.RLock()
+		effective := struct {
+			Generation      int64                          `json:"generation"`
+			ResourceVersion string                         `json:"resourceVersion,omitempty"`
+			Layers          []Layer                        `json:"layers"`
+			Config          config.Config                  `json:"config"`
+			MetricLabels    validations.MetricLabelsConfig `json:"metricLabels"`
+			Namespaces      NamespacesConfig               `json:"namespaces"`
+			Replicas        validations.ReplicasConfig     `json:"replicas"`
+			Ownership       validations.OwnershipConfig    `json:"ownership"`
+			Grouping        GroupingConfig                 `json:"grouping"`
+			Images          validations.ImagesConfig       `json:"images"`
+		}{
+			Generation:      cmw.snapshot.generation,
+			ResourceVersion: cmw.snapshot.resourceVersion,
+			Layers:          cmw.layers(),
+			Config:          cmw.snapshot.cfg,
+			MetricLabels:    cmw.snapshot.metricLabels,
+			Namespaces:      cmw.snapshot.namespaces,
+			Replicas:        cmw.snapshot.replicas,
+			Ownership:       cmw.snapshot.ownership,
+			Grouping:        cmw.snapshot.grouping,
+			Images:          cmw.snapshot.images,
+		}
+		cmw.mux.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(effective); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// layers returns all the current configuration layers by increasing precedence.
+// The caller must hold the lock.
+func (cmw *Watcher) layers() []Layer {
+	layers := cmw.baseLayers()
+	if cmw.clusterLayer != nil {
+		layers = append(layers, *cmw.clusterLayer)
+	}
+
+	return append(layers, cmw.sortedExtraLayers()...)
+}
+
+// baseLayers returns the layers which do not come from the cluster, the defaults and the file.
+// The caller must hold the lock.
+func (cmw *Watcher) baseLayers() []Layer {
+	layers := []Layer{newDefaultLayer()}
+	if cmw.fileLayer != nil {
+		layers = append(layers, *cmw.fileLayer)
+	}
+
+	return layers
+}
+
+// sortedExtraLayers returns the layers from labelled ConfigMaps ordered by name.
+// The caller must hold the lock.
+func (cmw *Watcher) sortedExtraLayers() []Layer {
+	names := make([]string, 0, len(cmw.extraLayers))
+	for name := range cmw.extraLayers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	layers := make([]Layer, 0, len(names))
+	for _, name := range names {
+		layers = append(layers, cmw.extraLayers[name])
+	}
+
+	return layers
+}
+
+// setLayer parses the configuration of the given ConfigMap into its layer and
+// publishes a new Snapshot. It returns false if the data cannot be parsed.
+func (cmw *Watcher) setLayer(cm *apicorev1.ConfigMap) bool {
+	layer, err := newLayer(layerSource(cm), cm.Data[configMapDataAccess])
+	if err != nil {
+		cmw.logger.Error(err, "ConfigMap data format", "name", cm.GetName())
+		return false
+	}
+	layer.resourceVersion = cm.GetResourceVersion()
+
+	cmw.mux.Lock()
+	defer cmw.mux.Unlock()
+
+	if configMapName == cm.GetName() {
+		cmw.clusterLayer = &layer
+	} else {
+		cmw.extraLayers[cm.GetName()] = layer
+	}
+	cmw.publish()
+
+	return true
+}
+
+// removeLayer forgets the layer of the given ConfigMap and publishes a new Snapshot
+func (cmw *Watcher) removeLayer(cm *apicorev1.ConfigMap) {
+	cmw.mux.Lock()
+	defer cmw.mux.Unlock()
+
+	if configMapName == cm.GetName() {
+		cmw.clusterLayer = nil
+	} else {
+		delete(cmw.extraLayers, cm.GetName())
+	}
+	cmw.publish()
+}
+
+// setStagedLayer replaces the staged layer and notifies about the change
+// without blocking, a pending notification already covering it
+func (cmw *Watcher) setStagedLayer(layer *Layer) {
+	cmw.mux.Lock()
+	defer cmw.mux.Unlock()
+
+	cmw.stagedLayer = layer
+
+	select {
+	case cmw.stagedCh <- struct{}{}:
+	default:
+	}
+}
+
+func (cmw *Watcher) hasStagedConfig() bool {
+	cmw.mux.RLock()
+	defer cmw.mux.RUnlock()
+
+	return cmw.stagedLayer != nil
+}
+
+// publishStagedConfig saves the staged configuration found in the given ConfigMap,
+// or forgets it if the key has been removed, and notifies about the change
+func (cmw *Watcher) publishStagedConfig(cm *apicorev1.ConfigMap) {
+	data, ok := cm.Data[configMapStagedDataAccess]
+	if !ok {
+		cmw.logger.Info("the staged configuration has been removed", "name", cm.GetName())
+
+		cmw.setStagedLayer(nil)
+		return
+	}
+
+	layer, err := newLayer("staged:"+layerSource(cm), data)
+	if err != nil {
+		cmw.logger.Error(err, "staged ConfigMap data format")
+		return
+	}
+
+	cmw.logger.Info("a staged configuration has been found", "name", cm.GetName())
+
+	cmw.setStagedLayer(&layer)
+}
+
+// stagedDataChanged returns true if the staged configuration has been
+// added, modified or removed between both versions of the ConfigMap
+func stagedDataChanged(oldCm, newCm *apicorev1.ConfigMap) bool {
+	oldData, oldOk := oldCm.Data[configMapStagedDataAccess]
+	newData, newOk := newCm.Data[configMapStagedDataAccess]
+
+	return oldOk != newOk || oldData != newData
+}
+
+// isConfigLayer returns true if the ConfigMap is labelled as an additional configuration layer
+func isConfigLayer(cm *apicorev1.ConfigMap) bool {
+	return cm.GetName() != configMapName && cm.GetLabels()[configLayerLabel] == "true"
+}
+
+func layerSource(cm *apicorev1.ConfigMap) string {
+	return fmt.Sprintf("configmap:%s/%s", cm.GetNamespace(), cm.GetName())
 }
 
 // readConfig returns a valid Kube-linter Config structure
 // based on the checks received by the string
 func readConfig(data string) (config.Config, error) {
-	var cfg config.Config
+	// the DVO settings are accepted next to the kube-linter ones
+	var cfg struct {
+		config.Config
+		MetricLabels validations.MetricLabelsConfig `json:"metricLabels"`
+		Namespaces   NamespacesConfig               `json:"namespaces"`
+		Replicas     validations.ReplicasConfig     `json:"replicas"`
+		Ownership    validations.OwnershipConfig    `json:"ownership"`
+		Grouping     GroupingConfig                 `json:"grouping"`
+		Images       validations.ImagesConfig       `json:"images"`
+	}
 
 	err := yaml.Unmarshal([]byte(data), &cfg, yaml.DisallowUnknownFields)
 	if err != nil {
-		return cfg, fmt.Errorf("unmarshalling configmap data: %w", err)
+		return cfg.Config, fmt.Errorf("unmarshalling configmap data: %w", err)
 	}
 
-	return cfg, nil
+	return cfg.Config, nil
 }
 
 func getPodNamespace() (string, error) {
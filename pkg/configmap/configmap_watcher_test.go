@@ -2,10 +2,14 @@ package configmap
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"golang.stackrox.io/kube-linter/pkg/config"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestReadConfig(t *testing.T) {
@@ -123,3 +127,119 @@ customChecks:
 		})
 	}
 }
+
+func TestStagedDataChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldData  map[string]string
+		newData  map[string]string
+		expected bool
+	}{
+		{
+			name:     "staged configuration added",
+			oldData:  map[string]string{configMapDataAccess: "checks: {}"},
+			newData:  map[string]string{configMapDataAccess: "checks: {}", configMapStagedDataAccess: "checks: {}"}, // nolint: lll
+			expected: true,
+		},
+		{
+			name:     "staged configuration removed",
+			oldData:  map[string]string{configMapStagedDataAccess: "checks: {}"},
+			newData:  map[string]string{},
+			expected: true,
+		},
+		{
+			name:     "staged configuration modified",
+			oldData:  map[string]string{configMapStagedDataAccess: "checks: {}"},
+			newData:  map[string]string{configMapStagedDataAccess: "checks: {addAllBuiltIn: true}"},
+			expected: true,
+		},
+		{
+			name:     "only the active configuration modified",
+			oldData:  map[string]string{configMapDataAccess: "checks: {}", configMapStagedDataAccess: "checks: {}"},                    // nolint: lll
+			newData:  map[string]string{configMapDataAccess: "checks: {addAllBuiltIn: true}", configMapStagedDataAccess: "checks: {}"}, // nolint: lll
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldCm := &apicorev1.ConfigMap{Data: tt.oldData}
+			newCm := &apicorev1.ConfigMap{Data: tt.newData}
+
+			assert.Equal(t, tt.expected, stagedDataChanged(oldCm, newCm))
+		})
+	}
+}
+
+func TestWatcherLayers(t *testing.T) {
+	newConfigMap := func(name string, labels map[string]string, data string) *apicorev1.ConfigMap {
+		return &apicorev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dvo", Labels: labels},
+			Data:       map[string]string{configMapDataAccess: data},
+		}
+	}
+	layerLabels := map[string]string{configLayerLabel: "true"}
+
+	cmw := newWatcher(nil, "dvo", "")
+
+	// cluster ConfigMap and labelled ConfigMaps are merged on top of the defaults
+	assert.True(t, cmw.setLayer(newConfigMap(configMapName, nil, `checks: {exclude: ["host-pid"]}`)))
+	assert.True(t, cmw.setLayer(newConfigMap("team-b", layerLabels, `checks: {include: ["team-b"]}`)))
+	assert.True(t, cmw.setLayer(newConfigMap("team-a", layerLabels, `checks: {include: ["team-a"]}`)))
+	assert.False(t, cmw.setLayer(newConfigMap("team-c", layerLabels, `checks: {unknown: true}`)))
+
+	cfg := cmw.CurrentConfig().Config()
+	assert.Equal(t, []string{"host-pid"}, cfg.Checks.Exclude)
+	assert.Equal(t, []string{"team-a", "team-b"}, cfg.Checks.Include[len(cfg.Checks.Include)-2:])
+
+	recorder := httptest.NewRecorder()
+	cmw.EffectiveConfigHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/config", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"source":"defaults"`)
+	assert.Contains(t, recorder.Body.String(), `"source":"configmap:dvo/team-a"`)
+
+	// removing the layers falls back to the defaults
+	cmw.removeLayer(newConfigMap(configMapName, nil, ""))
+	cmw.removeLayer(newConfigMap("team-a", layerLabels, ""))
+	cmw.removeLayer(newConfigMap("team-b", layerLabels, ""))
+	assert.Equal(t, MergeLayers([]Layer{newDefaultLayer()}), cmw.CurrentConfig().Config())
+}
+
+func TestStagedConfig(t *testing.T) {
+	// Given
+	cmw := newWatcher(nil, "dvo", "")
+	assert.True(t, cmw.setLayer(&apicorev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "dvo"},
+		Data:       map[string]string{configMapDataAccess: `images: {deniedRegistries: ["docker.io"]}`},
+	}))
+	cmw.MarkActive(cmw.CurrentConfig())
+	_, ok := cmw.StagedConfig()
+	assert.False(t, ok)
+	staged, err := newLayer("staged", `
+images: {deniedRegistries: ["quay.io"]}
+replicas: {validateScaledToZero: true}
+ownership: {namespaceAnnotations: ["example.com/team"]}`)
+	assert.NoError(t, err)
+
+	// When
+	cmw.setStagedLayer(&staged)
+	snapshot, ok := cmw.StagedConfig()
+
+	// Assert
+	assert.True(t, ok)
+	assert.Equal(t, []string{"quay.io"}, snapshot.Images().DeniedRegistries)
+	assert.True(t, snapshot.Replicas().ValidateScaledToZero)
+	assert.Equal(t, []string{"example.com/team"}, snapshot.Ownership().NamespaceAnnotations)
+	assert.Equal(t, []string{"docker.io"}, cmw.ActiveConfig().Images().DeniedRegistries,
+		"the staged configuration is not applied")
+	assert.Equal(t, int64(1), cmw.ActiveConfig().Generation())
+}
+
+func TestIsConfigLayer(t *testing.T) {
+	assert.True(t, isConfigLayer(&apicorev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name: "team-a", Labels: map[string]string{configLayerLabel: "true"},
+	}}))
+	assert.False(t, isConfigLayer(&apicorev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}))
+	assert.False(t, isConfigLayer(&apicorev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name: configMapName, Labels: map[string]string{configLayerLabel: "true"},
+	}}))
+}
@@ -0,0 +1,111 @@
+package configmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configDirRetryInterval is the interval the directory of the configuration
+// file is looked up at while it does not exist
+var configDirRetryInterval = 10 * time.Second
+
+// watchConfigFile reloads the file layer whenever the configuration file changes.
+// The parent directory is watched rather than the file itself, as files mounted from
+// ConfigMaps, Secrets or projected volumes are replaced by swapping symlinks.
+func (cmw *Watcher) watchConfigFile(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("initializing file watcher: %w", err)
+	}
+	defer fw.Close()
+
+	if ok, err := cmw.watchConfigDir(ctx, fw); err != nil || !ok {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if cmw.reloadConfigFile() {
+				cmw.logger.Info("the configuration file has been updated", "path", cmw.configFile)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			cmw.logger.Error(err, "file watcher", "path", cmw.configFile)
+		}
+	}
+}
+
+// watchConfigDir adds the directory of the configuration file to the given watcher.
+// If the directory does not exist yet, e.g. the volume is not mounted, it is looked
+// up again every configDirRetryInterval, and the file is read once it is watched.
+// It returns false if the context is done before the directory exists.
+func (cmw *Watcher) watchConfigDir(ctx context.Context, fw *fsnotify.Watcher) (bool, error) {
+	dir := filepath.Dir(cmw.configFile)
+	missing := false
+	for {
+		err := fw.Add(dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("watching directory of %s: %w", cmw.configFile, err)
+		}
+		if !missing {
+			cmw.logger.Info("the directory of the configuration file does not exist, waiting for it",
+				"path", cmw.configFile)
+			missing = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-time.After(configDirRetryInterval):
+		}
+	}
+
+	if missing && cmw.reloadConfigFile() {
+		cmw.logger.Info("the configuration file has been updated", "path", cmw.configFile)
+	}
+	return true, nil
+}
+
+// reloadConfigFile reads the configuration file again and publishes a new Snapshot
+// if its content changed. It returns true if the configuration has been updated.
+func (cmw *Watcher) reloadConfigFile() bool {
+	layer, ok, err := newFileLayer(cmw.configFile)
+	if err != nil {
+		cmw.logger.Error(err, "configuration file format", "path", cmw.configFile)
+		return false
+	}
+
+	var fileLayer *Layer
+	if ok {
+		fileLayer = &layer
+	}
+
+	cmw.mux.Lock()
+	defer cmw.mux.Unlock()
+
+	if reflect.DeepEqual(cmw.fileLayer, fileLayer) {
+		return false
+	}
+	cmw.fileLayer = fileLayer
+	cmw.publish()
+
+	return true
+}
@@ -0,0 +1,108 @@
+package configmap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cmw := Watcher{configFile: path, extraLayers: map[string]Layer{}}
+
+	// no file, no change
+	assert.False(t, cmw.reloadConfigFile())
+
+	// file created
+	assert.NoError(t, os.WriteFile(path, []byte(`checks: {exclude: ["host-pid"]}`), 0o600))
+	assert.True(t, cmw.reloadConfigFile())
+	assert.Equal(t, []string{"host-pid"}, cmw.CurrentConfig().Config().Checks.Exclude)
+
+	// same content, no change
+	assert.False(t, cmw.reloadConfigFile())
+
+	// invalid content keeps the previous configuration
+	assert.NoError(t, os.WriteFile(path, []byte(`checks: {unknown: true}`), 0o600))
+	assert.False(t, cmw.reloadConfigFile())
+	assert.Equal(t, []string{"host-pid"}, cmw.CurrentConfig().Config().Checks.Exclude)
+
+	// file removed
+	assert.NoError(t, os.Remove(path))
+	assert.True(t, cmw.reloadConfigFile())
+	assert.Empty(t, cmw.CurrentConfig().Config().Checks.Exclude)
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	cmw := newWatcher(nil, "", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmw.watchConfigFile(ctx)
+	}()
+
+	// the update is done the way the kubelet does for mounted ConfigMaps, through a rename
+	assert.Eventually(t, func() bool {
+		tmp := filepath.Join(dir, "..tmp")
+		data := []byte(`checks: {include: ["host-ipc"]}`)
+		if err := os.WriteFile(tmp, data, 0o600); err != nil {
+			return false
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return false
+		}
+
+		select {
+		case s := <-cmw.ConfigChanged():
+			return s.Generation() > 0
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Contains(t, cmw.CurrentConfig().Config().Checks.Include, "host-ipc")
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestWatchConfigFileMissingDirectory(t *testing.T) {
+	// Given
+	defer func(interval time.Duration) { configDirRetryInterval = interval }(configDirRetryInterval)
+	configDirRetryInterval = 10 * time.Millisecond
+	dir := filepath.Join(t.TempDir(), "config")
+	path := filepath.Join(dir, "config.yaml")
+	cmw := newWatcher(nil, "", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmw.watchConfigFile(ctx)
+	}()
+
+	// When
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, os.Mkdir(dir, 0o700))
+	assert.NoError(t, os.WriteFile(path, []byte(`checks: {include: ["host-ipc"]}`), 0o600))
+
+	// Assert
+	select {
+	case s := <-cmw.ConfigChanged():
+		assert.Contains(t, s.Config().Checks.Include, "host-ipc")
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the configuration file was not read once its directory was created")
+	}
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
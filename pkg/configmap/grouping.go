@@ -0,0 +1,23 @@
+package configmap
+
+// GroupingConfig sets how the objects of a namespace are grouped, each group
+// being validated together so the checks can relate its objects
+type GroupingConfig struct {
+	// HelmReleases groups the objects of each deployed Helm release, as listed in
+	// its release Secret, rather than by their labels
+	HelmReleases bool `json:"helmReleases"`
+}
+
+// MergeGrouping merges the grouping configuration of the given layers, each
+// setting being taken from the layer with the highest precedence defining it
+func MergeGrouping(layers []Layer) GroupingConfig {
+	var merged GroupingConfig
+
+	for _, layer := range layers {
+		if layer.helmReleases != nil {
+			merged.HelmReleases = *layer.helmReleases
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,353 @@
+package configmap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/ghodss/yaml"
+	"golang.stackrox.io/kube-linter/pkg/config"
+)
+
+// Layer is a single source of configuration. The effective configuration
+// is the result of merging all the layers by increasing precedence:
+//   - the embedded default checks
+//   - the configuration file
+//   - the cluster ConfigMap
+//   - the additional labelled ConfigMaps, ordered by name
+type Layer struct {
+	Source       string                         `json:"source"`
+	Config       config.Config                  `json:"config"`
+	MetricLabels validations.MetricLabelsConfig `json:"metricLabels"`
+	Namespaces   NamespacesConfig               `json:"namespaces"`
+	Replicas     validations.ReplicasConfig     `json:"replicas"`
+	Ownership    validations.OwnershipConfig    `json:"ownership"`
+	Grouping     GroupingConfig                 `json:"grouping"`
+	Images       validations.ImagesConfig       `json:"images"`
+
+	// boolean settings explicitly defined by the layer, as their
+	// zero value cannot be told apart from an unset one
+	addAllBuiltIn        *bool
+	doNotAutoAddDefaults *bool
+	validateScaledToZero *bool
+	helmReleases         *bool
+	scaledToZeroLabel    *bool
+	rootOwnerLabel       *bool
+	teamLabel            *bool
+
+	// resourceVersion of the ConfigMap defining the layer, if any
+	resourceVersion string
+	// defaults is true for the layer of the embedded default checks
+	defaults bool
+}
+
+// newLayer parses the given configuration data into a Layer
+func newLayer(source, data string) (Layer, error) {
+	cfg, err := readConfig(data)
+	if err != nil {
+		return Layer{}, err
+	}
+
+	var explicit struct {
+		Checks struct {
+			AddAllBuiltIn        *bool `json:"addAllBuiltIn"`
+			DoNotAutoAddDefaults *bool `json:"doNotAutoAddDefaults"`
+		} `json:"checks"`
+		MetricLabels struct {
+			validations.MetricLabelsConfig
+			ScaledToZero *bool `json:"scaledToZero"`
+			RootOwner    *bool `json:"rootOwner"`
+			Team         *bool `json:"team"`
+		} `json:"metricLabels"`
+		Namespaces NamespacesConfig `json:"namespaces"`
+		Replicas   struct {
+			ValidateScaledToZero *bool `json:"validateScaledToZero"`
+		} `json:"replicas"`
+		Ownership validations.OwnershipConfig `json:"ownership"`
+		Grouping  struct {
+			HelmReleases *bool `json:"helmReleases"`
+		} `json:"grouping"`
+		Images validations.ImagesConfig `json:"images"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &explicit); err != nil {
+		return Layer{}, fmt.Errorf("unmarshalling configmap data: %w", err)
+	}
+	if err := explicit.Namespaces.Validate(); err != nil {
+		return Layer{}, err
+	}
+	if err := explicit.Ownership.Validate(); err != nil {
+		return Layer{}, err
+	}
+	if err := explicit.Images.Validate(); err != nil {
+		return Layer{}, err
+	}
+
+	layer := Layer{
+		Source:               source,
+		Config:               cfg,
+		MetricLabels:         explicit.MetricLabels.MetricLabelsConfig,
+		Namespaces:           explicit.Namespaces,
+		Ownership:            explicit.Ownership,
+		Images:               explicit.Images,
+		addAllBuiltIn:        explicit.Checks.AddAllBuiltIn,
+		doNotAutoAddDefaults: explicit.Checks.DoNotAutoAddDefaults,
+		validateScaledToZero: explicit.Replicas.ValidateScaledToZero,
+		helmReleases:         explicit.Grouping.HelmReleases,
+		scaledToZeroLabel:    explicit.MetricLabels.ScaledToZero,
+		rootOwnerLabel:       explicit.MetricLabels.RootOwner,
+		teamLabel:            explicit.MetricLabels.Team,
+	}
+	if layer.validateScaledToZero != nil {
+		layer.Replicas.ValidateScaledToZero = *layer.validateScaledToZero
+	}
+	if layer.helmReleases != nil {
+		layer.Grouping.HelmReleases = *layer.helmReleases
+	}
+	if layer.scaledToZeroLabel != nil {
+		layer.MetricLabels.ScaledToZero = *layer.scaledToZeroLabel
+	}
+	if layer.rootOwnerLabel != nil {
+		layer.MetricLabels.RootOwner = *layer.rootOwnerLabel
+	}
+	if layer.teamLabel != nil {
+		layer.MetricLabels.Team = *layer.teamLabel
+	}
+	return layer, nil
+}
+
+// newDefaultLayer returns the layer holding the embedded default checks
+func newDefaultLayer() Layer {
+	checks := validations.GetDefaultChecks()
+
+	return Layer{
+		Source:               "defaults",
+		Config:               config.Config{Checks: checks},
+		defaults:             true,
+		addAllBuiltIn:        &checks.AddAllBuiltIn,
+		doNotAutoAddDefaults: &checks.DoNotAutoAddDefaults,
+	}
+}
+
+// newFileLayer returns the layer defined by the configuration file in the given path.
+// The returned boolean is false if there is no such file.
+func newFileLayer(path string) (Layer, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return Layer{}, false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Layer{}, false, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+
+	layer, err := newLayer("file:"+path, string(data))
+	if err != nil {
+		return Layer{}, false, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return layer, true, nil
+}
+
+// MergeLayers merges the given layers, sorted by increasing precedence, into a single configuration:
+//   - boolean settings are taken from the layer with the highest precedence defining them
+//   - included and excluded checks are the union of all the layers, a layer including a check
+//     excluded by a lower layer, or the other way around, overriding it
+//   - the embedded default checks are not included when doNotAutoAddDefaults is set by
+//     another layer, which then replaces them as before layering
+//   - custom checks are merged by name, a layer overriding the checks with the same name
+func MergeLayers(layers []Layer) config.Config {
+	var merged config.Config
+	customChecks := map[string]int{}
+
+	replaceDefaults := false
+	for _, layer := range layers {
+		if !layer.defaults && layer.doNotAutoAddDefaults != nil {
+			replaceDefaults = *layer.doNotAutoAddDefaults
+		}
+	}
+
+	for _, layer := range layers {
+		if layer.addAllBuiltIn != nil {
+			merged.Checks.AddAllBuiltIn = *layer.addAllBuiltIn
+		}
+		if layer.doNotAutoAddDefaults != nil {
+			merged.Checks.DoNotAutoAddDefaults = *layer.doNotAutoAddDefaults
+		}
+
+		include, exclude := layer.Config.Checks.Include, layer.Config.Checks.Exclude
+		if layer.defaults && replaceDefaults {
+			include = nil
+		}
+		merged.Checks.Include = appendMissing(removeValues(merged.Checks.Include, exclude), include)
+		merged.Checks.Exclude = appendMissing(removeValues(merged.Checks.Exclude, include), exclude)
+
+		for _, check := range layer.Config.CustomChecks {
+			if i, ok := customChecks[check.Name]; ok {
+				merged.CustomChecks[i] = check
+				continue
+			}
+			customChecks[check.Name] = len(merged.CustomChecks)
+			merged.CustomChecks = append(merged.CustomChecks, check)
+		}
+	}
+
+	return merged
+}
+
+// MergeMetricLabels merges the metric labels configuration of the given layers,
+// the labels promoted being the union of the ones of all the layers. The
+// scaledToZero, rootOwner and team settings are taken from the layer with the
+// highest precedence defining them, the team label being added by default as
+// soon as a layer attributes the objects to teams.
+func MergeMetricLabels(layers []Layer) validations.MetricLabelsConfig {
+	var merged validations.MetricLabelsConfig
+	var team *bool
+
+	for _, layer := range layers {
+		merged.ObjectLabels = appendMissing(merged.ObjectLabels, layer.MetricLabels.ObjectLabels)
+		merged.ObjectAnnotations = appendMissing(merged.ObjectAnnotations, layer.MetricLabels.ObjectAnnotations)
+		merged.NamespaceLabels = appendMissing(merged.NamespaceLabels, layer.MetricLabels.NamespaceLabels)
+		if layer.scaledToZeroLabel != nil {
+			merged.ScaledToZero = *layer.scaledToZeroLabel
+		}
+		if layer.rootOwnerLabel != nil {
+			merged.RootOwner = *layer.rootOwnerLabel
+		}
+		if layer.teamLabel != nil {
+			team = layer.teamLabel
+		}
+		merged.Team = merged.Team || layer.Ownership.Enabled()
+	}
+	if team != nil {
+		merged.Team = *team
+	}
+
+	return merged
+}
+
+// MergeReplicas merges the replicas configuration of the given layers, each
+// setting being taken from the layer with the highest precedence defining it
+func MergeReplicas(layers []Layer) validations.ReplicasConfig {
+	var merged validations.ReplicasConfig
+
+	for _, layer := range layers {
+		if layer.validateScaledToZero != nil {
+			merged.ValidateScaledToZero = *layer.validateScaledToZero
+		}
+	}
+
+	return merged
+}
+
+// MergeOwnership merges the ownership configuration of the given layers. The annotations
+// are the union of the ones of all the layers, by increasing precedence, and the mappings
+// are concatenated, so the rules of a layer take precedence over the ones of the previous layers.
+func MergeOwnership(layers []Layer) validations.OwnershipConfig {
+	var merged validations.OwnershipConfig
+
+	for _, layer := range layers {
+		merged.ObjectAnnotations = prependMissing(merged.ObjectAnnotations, layer.Ownership.ObjectAnnotations)
+		merged.NamespaceAnnotations = prependMissing(merged.NamespaceAnnotations,
+			layer.Ownership.NamespaceAnnotations)
+		if strings.TrimSpace(layer.Ownership.Mapping) != "" {
+			merged.Mapping += strings.TrimRight(layer.Ownership.Mapping, "\n") + "\n"
+		}
+	}
+
+	return merged
+}
+
+// MergeImages merges the image checks configuration of the given layers. The allowed
+// registries are the intersection of the ones of the layers setting them, so a layer can
+// only restrict them. The other lists are the union of the ones of all the layers.
+func MergeImages(layers []Layer) validations.ImagesConfig {
+	var merged validations.ImagesConfig
+
+	for _, layer := range layers {
+		if len(layer.Images.AllowedRegistries) > 0 {
+			if merged.AllowedRegistries == nil {
+				merged.AllowedRegistries = append([]string{}, layer.Images.AllowedRegistries...)
+			} else {
+				merged.AllowedRegistries = intersectRegistries(merged.AllowedRegistries,
+					layer.Images.AllowedRegistries)
+			}
+		}
+		merged.DeniedRegistries = appendMissing(merged.DeniedRegistries, layer.Images.DeniedRegistries)
+		merged.DigestExemptRegistries = appendMissing(merged.DigestExemptRegistries,
+			layer.Images.DigestExemptRegistries)
+		merged.MutableTags = appendMissing(merged.MutableTags, layer.Images.MutableTags)
+	}
+
+	return merged
+}
+
+// intersectRegistries returns the registries, or repositories, part of both the given ones,
+// e.g. quay.io/example for quay.io and quay.io/example. The result is empty, not nil, when
+// they have none in common.
+func intersectRegistries(a, b []string) []string {
+	within := func(registry string, registries []string) bool {
+		registry = strings.TrimSuffix(registry, "/")
+		for _, r := range registries {
+			r = strings.TrimSuffix(r, "/")
+			if registry == r || strings.HasPrefix(registry, r+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	intersection := []string{}
+	for _, registry := range a {
+		if within(registry, b) {
+			intersection = appendMissing(intersection, []string{registry})
+		}
+	}
+	for _, registry := range b {
+		if within(registry, a) {
+			intersection = appendMissing(intersection, []string{registry})
+		}
+	}
+	return intersection
+}
+
+// removeValues returns the values of src which are not part of values
+func removeValues(src, values []string) []string {
+	var kept []string
+	for _, s := range src {
+		found := false
+		for _, v := range values {
+			if s == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// prependMissing returns the given values followed by the ones of dst which are not part of them
+func prependMissing(dst, values []string) []string {
+	return appendMissing(append([]string(nil), values...), dst)
+}
+
+// appendMissing appends to dst the values which are not already part of it
+func appendMissing(dst, values []string) []string {
+	for _, v := range values {
+		found := false
+		for _, d := range dst {
+			if d == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,386 @@
+package configmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withoutCheck(checks []string, check string) []string {
+	var kept []string
+	for _, c := range checks {
+		if c != check {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func TestMergeLayers(t *testing.T) {
+	mustLayer := func(source, data string) Layer {
+		layer, err := newLayer(source, data)
+		assert.NoError(t, err)
+		return layer
+	}
+
+	defaultsWithoutHostPID := withoutCheck(validations.GetDefaultChecks().Include, "host-pid")
+
+	tests := []struct {
+		name     string
+		layers   []Layer
+		expected config.Config
+	}{
+		{
+			name:     "defaults only",
+			layers:   []Layer{newDefaultLayer()},
+			expected: config.Config{Checks: validations.GetDefaultChecks()},
+		},
+		{
+			name: "booleans are taken from the last layer defining them",
+			layers: []Layer{
+				newDefaultLayer(),
+				mustLayer("file", `
+checks:
+  addAllBuiltIn: true`),
+				mustLayer("cluster", `
+checks:
+  exclude: ["host-pid"]`),
+			},
+			expected: config.Config{
+				Checks: config.ChecksConfig{
+					AddAllBuiltIn:        true,
+					DoNotAutoAddDefaults: true,
+					Include:              defaultsWithoutHostPID,
+					Exclude:              []string{"host-pid"},
+				},
+			},
+		},
+		{
+			name: "checks lists are merged and custom checks overridden by name",
+			layers: []Layer{
+				mustLayer("cluster", `
+checks:
+  doNotAutoAddDefaults: true
+  include: ["host-pid", "host-ipc"]
+customChecks:
+- name: team-check
+  template: minimum-replicas
+  description: "cluster"`),
+				mustLayer("team-a", `
+checks:
+  doNotAutoAddDefaults: false
+  include: ["host-ipc", "host-network"]
+customChecks:
+- name: team-check
+  template: minimum-replicas
+  description: "team-a"`),
+			},
+			expected: config.Config{
+				Checks: config.ChecksConfig{
+					Include: []string{"host-pid", "host-ipc", "host-network"},
+				},
+				CustomChecks: []config.Check{
+					{Name: "team-check", Template: "minimum-replicas", Description: "team-a"},
+				},
+			},
+		},
+		{
+			name: "doNotAutoAddDefaults replaces the default checks",
+			layers: []Layer{
+				newDefaultLayer(),
+				mustLayer("cluster", `
+checks:
+  doNotAutoAddDefaults: true
+  include: ["host-pid"]`),
+			},
+			expected: config.Config{
+				Checks: config.ChecksConfig{
+					DoNotAutoAddDefaults: true,
+					Include:              []string{"host-pid"},
+				},
+			},
+		},
+		{
+			name: "a higher layer overrides the inclusion or exclusion of a lower one",
+			layers: []Layer{
+				mustLayer("file", `
+checks:
+  include: ["host-pid", "host-ipc"]
+  exclude: ["host-network"]`),
+				mustLayer("cluster", `
+checks:
+  include: ["host-network"]
+  exclude: ["host-pid"]`),
+			},
+			expected: config.Config{
+				Checks: config.ChecksConfig{
+					Include: []string{"host-ipc", "host-network"},
+					Exclude: []string{"host-pid"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MergeLayers(tt.layers))
+		})
+	}
+}
+
+func TestNewFileLayer(t *testing.T) {
+	t.Run("missing file is not a layer", func(t *testing.T) {
+		_, ok, err := newFileLayer(filepath.Join(t.TempDir(), "missing.yaml"))
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("existing file is parsed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("checks:\n  include: [\"host-pid\"]"), 0o600))
+
+		layer, ok, err := newFileLayer(path)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "file:"+path, layer.Source)
+		assert.Equal(t, []string{"host-pid"}, layer.Config.Checks.Include)
+	})
+
+	t.Run("invalid file returns an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("checks:\n  unknown: true"), 0o600))
+
+		_, _, err := newFileLayer(path)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMergeMetricLabels(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `
+checks:
+  include: ["host-pid"]
+metricLabels:
+  objectLabels: ["app.kubernetes.io/part-of"]
+  namespaceLabels: ["team"]`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `
+metricLabels:
+  objectLabels: ["app.kubernetes.io/part-of"]
+  objectAnnotations: ["example.com/owner-team"]`)
+	assert.NoError(t, err)
+
+	// When
+	merged := MergeMetricLabels([]Layer{newDefaultLayer(), file, cluster})
+
+	// Assert
+	assert.Equal(t, validations.MetricLabelsConfig{
+		ObjectLabels:      []string{"app.kubernetes.io/part-of"},
+		ObjectAnnotations: []string{"example.com/owner-team"},
+		NamespaceLabels:   []string{"team"},
+	}, merged)
+
+	_, err = newLayer("cluster", `metricLabels: {unknown: ["team"]}`)
+	assert.Error(t, err)
+}
+
+func TestMergeMetricLabelsSwitches(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `
+metricLabels:
+  scaledToZero: true
+  rootOwner: true
+ownership:
+  mapping: "payments-.* payments"`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `
+metricLabels:
+  scaledToZero: false
+  team: false`)
+	assert.NoError(t, err)
+	extra, err := newLayer("extra", `metricLabels: {objectLabels: ["app"]}`)
+	assert.NoError(t, err)
+
+	// When
+	merged := MergeMetricLabels([]Layer{newDefaultLayer(), file, cluster, extra})
+
+	// Assert
+	assert.Equal(t, validations.MetricLabelsConfig{
+		ObjectLabels: []string{"app"},
+		RootOwner:    true,
+	}, merged, "the cluster layer explicitly disables the scaled_to_zero and team labels")
+	assert.Equal(t, validations.MetricLabelsConfig{
+		ObjectLabels: []string{"app"},
+		ScaledToZero: true,
+		RootOwner:    true,
+		Team:         true,
+	}, MergeMetricLabels([]Layer{newDefaultLayer(), file, extra}))
+}
+
+func TestMergeReplicas(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `
+replicas:
+  validateScaledToZero: true
+metricLabels:
+  scaledToZero: true`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `
+replicas:
+  validateScaledToZero: false`)
+	assert.NoError(t, err)
+	extra, err := newLayer("extra", `checks: {include: ["host-pid"]}`)
+	assert.NoError(t, err)
+
+	// When
+	layers := []Layer{newDefaultLayer(), file, cluster, extra}
+
+	// Assert
+	assert.Equal(t, validations.ReplicasConfig{ValidateScaledToZero: true},
+		MergeReplicas([]Layer{newDefaultLayer(), file, extra}))
+	assert.Equal(t, validations.ReplicasConfig{}, MergeReplicas(layers),
+		"the cluster layer explicitly disables the validation")
+	assert.True(t, MergeMetricLabels(layers).ScaledToZero)
+}
+
+func TestMergeNamespaces(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `
+namespaces:
+  ignorePattern: "^(openshift.*|kube-.*)$"
+  excludeSelector: "dvo=skip"`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `
+namespaces:
+  ignorePattern: "^kube-.*$"
+  includeSelector: "env in (dev, stage)"
+  tiers:
+  - name: production
+    selector: "env=production"
+    interval: 2m`)
+	assert.NoError(t, err)
+
+	// When
+	merged := MergeNamespaces([]Layer{newDefaultLayer(), file, cluster})
+
+	// Assert
+	assert.Equal(t, NamespacesConfig{
+		IgnorePattern:   "^kube-.*$",
+		IncludeSelector: "env in (dev, stage)",
+		ExcludeSelector: "dvo=skip",
+		Tiers: []NamespaceTier{
+			{
+				Name:     "production",
+				Selector: "env=production",
+				Interval: metav1.Duration{Duration: 2 * time.Minute},
+			},
+		},
+	}, merged)
+
+	_, err = newLayer("cluster", `namespaces: {ignorePattern: "("}`)
+	assert.ErrorContains(t, err, "parsing namespaces ignore pattern")
+	_, err = newLayer("cluster", `namespaces: {excludeSelector: "env in ("}`)
+	assert.ErrorContains(t, err, "parsing namespaces exclude selector")
+	_, err = newLayer("cluster", `namespaces: {tiers: [{name: dev, selector: "env=dev"}]}`)
+	assert.ErrorContains(t, err, `interval of namespace tier "dev" must be positive`)
+}
+
+func TestMergeOwnership(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `
+ownership:
+  objectAnnotations: ["example.com/team"]
+  mapping: |
+    # platform namespaces
+    openshift-.* platform platform@example.com
+    payments-.* payments`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `
+ownership:
+  objectAnnotations: ["example.com/owner", "example.com/team"]
+  namespaceAnnotations: ["example.com/team"]
+  mapping: "payments-legacy billing"`)
+	assert.NoError(t, err)
+
+	// When
+	layers := []Layer{newDefaultLayer(), file, cluster}
+	merged := MergeOwnership(layers)
+
+	// Assert
+	assert.Equal(t, validations.OwnershipConfig{
+		ObjectAnnotations:    []string{"example.com/owner", "example.com/team"},
+		NamespaceAnnotations: []string{"example.com/team"},
+		Mapping: "# platform namespaces\nopenshift-.* platform platform@example.com\npayments-.* payments\n" +
+			"payments-legacy billing\n",
+	}, merged)
+	assert.True(t, MergeMetricLabels(layers).Team, "the team label is added along the ownership")
+	assert.False(t, MergeMetricLabels([]Layer{newDefaultLayer()}).Team)
+
+	_, err = newLayer("cluster", `ownership: {mapping: "payments-.*"}`)
+	assert.ErrorContains(t, err, "ownership mapping line 1")
+}
+
+func TestMergeGrouping(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `grouping: {helmReleases: true}`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `grouping: {helmReleases: false}`)
+	assert.NoError(t, err)
+	extra, err := newLayer("extra", `checks: {include: ["host-pid"]}`)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, GroupingConfig{HelmReleases: true}, MergeGrouping([]Layer{newDefaultLayer(), file, extra}))
+	assert.Equal(t, GroupingConfig{}, MergeGrouping([]Layer{newDefaultLayer(), file, cluster, extra}),
+		"the cluster layer explicitly disables the grouping by release")
+}
+
+func TestMergeImages(t *testing.T) {
+	// Given
+	file, err := newLayer("file", `images: {allowedRegistries: ["quay.io"], mutableTags: ["latest"]}`)
+	assert.NoError(t, err)
+	cluster, err := newLayer("cluster", `images: {allowedRegistries: ["quay.io", "registry.example.com"], `+
+		`deniedRegistries: ["quay.io/untrusted"], digestExemptRegistries: ["registry.example.com"]}`)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, validations.ImagesConfig{
+		AllowedRegistries:      []string{"quay.io"},
+		DeniedRegistries:       []string{"quay.io/untrusted"},
+		DigestExemptRegistries: []string{"registry.example.com"},
+		MutableTags:            []string{"latest"},
+	}, MergeImages([]Layer{newDefaultLayer(), file, cluster}),
+		"the cluster layer cannot widen the allowed registries")
+	assert.Nil(t, MergeImages([]Layer{newDefaultLayer()}).AllowedRegistries, "all the registries are allowed")
+
+	_, err = newLayer("invalid", `images: {deniedRegistries: [""]}`)
+	assert.Error(t, err)
+}
+
+func TestIntersectRegistries(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []string
+	}{
+		{"same registries", []string{"quay.io"}, []string{"quay.io"}, []string{"quay.io"}},
+		{"repository of a registry", []string{"quay.io", "docker.io"}, []string{"quay.io/example/"},
+			[]string{"quay.io/example/"}},
+		{"nothing in common", []string{"quay.io"}, []string{"docker.io"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, intersectRegistries(tt.a, tt.b))
+		})
+	}
+}
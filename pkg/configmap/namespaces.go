@@ -0,0 +1,78 @@
+package configmap
+
+import (
+	"fmt"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespacesConfig selects the namespaces whose objects are validated. A namespace
+// is validated if its name does not match IgnorePattern, its labels match
+// IncludeSelector and do not match ExcludeSelector. The dvo.openshift.io/validate
+// annotation of the namespaces takes precedence over these settings.
+type NamespacesConfig struct {
+	// IgnorePattern is a regular expression matching the names of the namespaces not validated
+	IgnorePattern string `json:"ignorePattern,omitempty"`
+	// IncludeSelector is a label selector the namespaces validated must match
+	IncludeSelector string `json:"includeSelector,omitempty"`
+	// ExcludeSelector is a label selector matching the namespaces not validated
+	ExcludeSelector string `json:"excludeSelector,omitempty"`
+	// Tiers set the validation interval of the namespaces, the first tier whose selector
+	// matches a namespace applying. The other namespaces are validated at the default interval.
+	Tiers []NamespaceTier `json:"tiers,omitempty"`
+}
+
+// NamespaceTier sets the validation interval of the namespaces matching its selector
+type NamespaceTier struct {
+	Name string `json:"name"`
+	// Selector is a label selector matching the namespaces of the tier, all of them when empty
+	Selector string          `json:"selector,omitempty"`
+	Interval metav1.Duration `json:"interval"`
+}
+
+// Validate returns an error if the pattern or one of the selectors cannot be parsed
+func (c NamespacesConfig) Validate() error {
+	if _, err := regexp.Compile(c.IgnorePattern); err != nil {
+		return fmt.Errorf("parsing namespaces ignore pattern: %w", err)
+	}
+	if _, err := labels.Parse(c.IncludeSelector); err != nil {
+		return fmt.Errorf("parsing namespaces include selector: %w", err)
+	}
+	if _, err := labels.Parse(c.ExcludeSelector); err != nil {
+		return fmt.Errorf("parsing namespaces exclude selector: %w", err)
+	}
+	for _, tier := range c.Tiers {
+		if _, err := labels.Parse(tier.Selector); err != nil {
+			return fmt.Errorf("parsing selector of namespace tier %q: %w", tier.Name, err)
+		}
+		if tier.Interval.Duration <= 0 {
+			return fmt.Errorf("interval of namespace tier %q must be positive", tier.Name)
+		}
+	}
+	return nil
+}
+
+// MergeNamespaces merges the namespaces configuration of the given layers, each setting
+// being taken from the layer with the highest precedence defining it, the tiers as a whole
+func MergeNamespaces(layers []Layer) NamespacesConfig {
+	var merged NamespacesConfig
+
+	for _, layer := range layers {
+		if layer.Namespaces.IgnorePattern != "" {
+			merged.IgnorePattern = layer.Namespaces.IgnorePattern
+		}
+		if layer.Namespaces.IncludeSelector != "" {
+			merged.IncludeSelector = layer.Namespaces.IncludeSelector
+		}
+		if layer.Namespaces.ExcludeSelector != "" {
+			merged.ExcludeSelector = layer.Namespaces.ExcludeSelector
+		}
+		if len(layer.Namespaces.Tiers) > 0 {
+			merged.Tiers = layer.Namespaces.Tiers
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,150 @@
+package configmap
+
+import (
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"golang.stackrox.io/kube-linter/pkg/config"
+)
+
+// Snapshot is an immutable version of the merged configuration published by the Watcher
+type Snapshot struct {
+	generation      int64
+	resourceVersion string
+	cfg             config.Config
+	metricLabels    validations.MetricLabelsConfig
+	namespaces      NamespacesConfig
+	replicas        validations.ReplicasConfig
+	ownership       validations.OwnershipConfig
+	grouping        GroupingConfig
+	images          validations.ImagesConfig
+}
+
+// mergeSnapshot merges the given layers, by increasing precedence, into a Snapshot
+// without generation nor resourceVersion
+func mergeSnapshot(layers []Layer) Snapshot {
+	return Snapshot{
+		cfg:          MergeLayers(layers),
+		metricLabels: MergeMetricLabels(layers),
+		namespaces:   MergeNamespaces(layers),
+		replicas:     MergeReplicas(layers),
+		ownership:    MergeOwnership(layers),
+		grouping:     MergeGrouping(layers),
+		images:       MergeImages(layers),
+	}
+}
+
+// FileConfig returns the Snapshot the Watcher publishes for the configuration file
+// in the given path without any cluster ConfigMap, i.e. the embedded default checks
+// merged with the file, if it exists, e.g. to validate manifests without a cluster
+func FileConfig(path string) (Snapshot, error) {
+	layers := []Layer{newDefaultLayer()}
+	layer, ok, err := newFileLayer(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if ok {
+		layers = append(layers, layer)
+	}
+	return mergeSnapshot(layers), nil
+}
+
+// Generation returns the sequence number of the snapshot. It increases
+// every time the Watcher publishes a new configuration.
+func (s Snapshot) Generation() int64 {
+	return s.generation
+}
+
+// ResourceVersion returns the resourceVersion of the cluster ConfigMap
+// the configuration has been built from, or an empty string if there is none
+func (s Snapshot) ResourceVersion() string {
+	return s.resourceVersion
+}
+
+// Config returns a copy of the kube-linter configuration, safe to be modified by the caller
+func (s Snapshot) Config() config.Config {
+	return copyConfig(s.cfg)
+}
+
+// MetricLabels returns a copy of the object and namespace metadata promoted to metric labels
+func (s Snapshot) MetricLabels() validations.MetricLabelsConfig {
+	return validations.MetricLabelsConfig{
+		ObjectLabels:      append([]string(nil), s.metricLabels.ObjectLabels...),
+		ObjectAnnotations: append([]string(nil), s.metricLabels.ObjectAnnotations...),
+		NamespaceLabels:   append([]string(nil), s.metricLabels.NamespaceLabels...),
+		ScaledToZero:      s.metricLabels.ScaledToZero,
+		RootOwner:         s.metricLabels.RootOwner,
+		Team:              s.metricLabels.Team,
+	}
+}
+
+// Namespaces returns the selection of the namespaces whose objects are validated
+func (s Snapshot) Namespaces() NamespacesConfig {
+	return s.namespaces
+}
+
+// Replicas returns how the workloads scaled to zero are validated
+func (s Snapshot) Replicas() validations.ReplicasConfig {
+	return s.replicas
+}
+
+// Grouping returns how the objects of a namespace are grouped
+func (s Snapshot) Grouping() GroupingConfig {
+	return s.grouping
+}
+
+// Ownership returns a copy of how the objects are attributed to teams
+func (s Snapshot) Ownership() validations.OwnershipConfig {
+	return validations.OwnershipConfig{
+		ObjectAnnotations:    append([]string(nil), s.ownership.ObjectAnnotations...),
+		NamespaceAnnotations: append([]string(nil), s.ownership.NamespaceAnnotations...),
+		Mapping:              s.ownership.Mapping,
+	}
+}
+
+// Images returns a copy of the parameters of the image checks
+func (s Snapshot) Images() validations.ImagesConfig {
+	return validations.ImagesConfig{
+		AllowedRegistries:      copyStrings(s.images.AllowedRegistries),
+		DeniedRegistries:       append([]string(nil), s.images.DeniedRegistries...),
+		DigestExemptRegistries: append([]string(nil), s.images.DigestExemptRegistries...),
+		MutableTags:            append([]string(nil), s.images.MutableTags...),
+	}
+}
+
+// copyStrings returns a copy of the given values, nil if they are
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+// copyConfig returns a deep copy of the slices and maps of the given configuration
+func copyConfig(cfg config.Config) config.Config {
+	cp := config.Config{
+		Checks: config.ChecksConfig{
+			AddAllBuiltIn:        cfg.Checks.AddAllBuiltIn,
+			DoNotAutoAddDefaults: cfg.Checks.DoNotAutoAddDefaults,
+			Include:              append([]string(nil), cfg.Checks.Include...),
+			Exclude:              append([]string(nil), cfg.Checks.Exclude...),
+		},
+	}
+
+	for _, check := range cfg.CustomChecks {
+		if check.Scope != nil {
+			scope := config.ObjectKindsDesc{
+				ObjectKinds: append([]string(nil), check.Scope.ObjectKinds...),
+			}
+			check.Scope = &scope
+		}
+		if check.Params != nil {
+			params := make(map[string]interface{}, len(check.Params))
+			for k, v := range check.Params {
+				params[k] = v
+			}
+			check.Params = params
+		}
+		cp.CustomChecks = append(cp.CustomChecks, check)
+	}
+
+	return cp
+}
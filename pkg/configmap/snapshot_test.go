@@ -0,0 +1,122 @@
+package configmap
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSnapshotConfigIsImmutable(t *testing.T) {
+	// Given
+	s := Snapshot{cfg: config.Config{
+		Checks: config.ChecksConfig{Include: []string{"host-ipc", "host-pid"}},
+		CustomChecks: []config.Check{{
+			Name:   "custom",
+			Scope:  &config.ObjectKindsDesc{ObjectKinds: []string{"DeploymentLike"}},
+			Params: map[string]interface{}{"key": "value"},
+		}},
+	}}
+
+	// When
+	cfg := s.Config()
+	cfg.Checks.Include[0] = "modified"
+	cfg.CustomChecks[0].Scope.ObjectKinds[0] = "modified"
+	cfg.CustomChecks[0].Params["key"] = "modified"
+
+	// Assert
+	assert.Equal(t, "host-ipc", s.Config().Checks.Include[0])
+	assert.Equal(t, "DeploymentLike", s.Config().CustomChecks[0].Scope.ObjectKinds[0])
+	assert.Equal(t, "value", s.Config().CustomChecks[0].Params["key"])
+}
+
+func TestWatcherPublishesSnapshots(t *testing.T) {
+	newConfigMap := func(rv, data string) *apicorev1.ConfigMap {
+		return &apicorev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "dvo", ResourceVersion: rv},
+			Data:       map[string]string{configMapDataAccess: data},
+		}
+	}
+
+	t.Run("pending snapshots are coalesced without blocking", func(t *testing.T) {
+		// Given
+		cmw := newWatcher(nil, "dvo", "")
+
+		// When
+		assert.True(t, cmw.setLayer(newConfigMap("1", `checks: {exclude: ["host-pid"]}`)))
+		assert.True(t, cmw.setLayer(newConfigMap("2", `checks: {exclude: ["host-ipc"]}`)))
+
+		// Assert
+		s := <-cmw.ConfigChanged()
+		assert.Equal(t, int64(2), s.Generation())
+		assert.Equal(t, "2", s.ResourceVersion())
+		assert.Equal(t, []string{"host-ipc"}, s.Config().Checks.Exclude)
+		assert.Equal(t, s, cmw.CurrentConfig())
+		assert.Empty(t, cmw.ConfigChanged())
+	})
+
+	t.Run("invalid data does not publish a snapshot", func(t *testing.T) {
+		// Given
+		cmw := newWatcher(nil, "dvo", "")
+
+		// When
+		assert.False(t, cmw.setLayer(newConfigMap("1", `checks: {unknown: true}`)))
+
+		// Assert
+		assert.Empty(t, cmw.ConfigChanged())
+		assert.Equal(t, int64(0), cmw.CurrentConfig().Generation())
+	})
+
+	t.Run("concurrent updates publish increasing generations", func(t *testing.T) {
+		// Given
+		cmw := newWatcher(nil, "dvo", "")
+		var wg sync.WaitGroup
+
+		// When
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cmw.setLayer(newConfigMap("1", `checks: {exclude: ["host-pid"]}`))
+				_ = cmw.CurrentConfig().Config()
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		assert.Equal(t, int64(10), (<-cmw.ConfigChanged()).Generation())
+	})
+}
+
+func TestFileConfig(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+checks: {exclude: ["host-pid"]}
+images: {deniedRegistries: ["docker.io"]}
+replicas: {validateScaledToZero: true}
+ownership: {objectAnnotations: ["example.com/team"]}`), 0o600))
+
+	// When
+	s, err := FileConfig(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host-pid"}, s.Config().Checks.Exclude)
+	assert.Equal(t, []string{"docker.io"}, s.Images().DeniedRegistries)
+	assert.True(t, s.Replicas().ValidateScaledToZero)
+	assert.Equal(t, []string{"example.com/team"}, s.Ownership().ObjectAnnotations)
+
+	defaults, err := FileConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, MergeLayers([]Layer{newDefaultLayer()}), defaults.Config())
+
+	assert.NoError(t, os.WriteFile(path, []byte(`checks: {unknown: true}`), 0o600))
+	_, err = FileConfig(path)
+	assert.Error(t, err)
+}
@@ -18,9 +18,13 @@ const (
 	EnvResorucesPerListQuery string = "RESOURCES_PER_LIST_QUERY"
 
 	// EnvNamespaceIgnorePattern sets the pattern for ignoring namespaces from the list of namespaces
-	// that are in the validate list of this operator
+	// that are in the validate list of this operator, unless set by the namespaces configuration
 	EnvNamespaceIgnorePattern string = "NAMESPACE_IGNORE_PATTERN"
 
+	// NamespaceValidateAnnotation opts a namespace in ("true") or out ("false") of the validation,
+	// regardless of the namespaces configuration
+	NamespaceValidateAnnotation string = "dvo.openshift.io/validate"
+
 	// EnvValidationCheckInterval sets the frequency of the kube-linter check validations in minutes
 	EnvValidationCheckInterval string = "VALIDATION_CHECK_INTERVAL"
 )
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -19,6 +21,9 @@ import (
 	"k8s.io/client-go/discovery"
 
 	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+	"github.com/app-sre/deployment-validation-operator/pkg/history"
+	"github.com/app-sre/deployment-validation-operator/pkg/notify"
+	"github.com/app-sre/deployment-validation-operator/pkg/remediation"
 	"github.com/app-sre/deployment-validation-operator/pkg/utils"
 	"github.com/app-sre/deployment-validation-operator/pkg/validations"
 	"github.com/go-logr/logr"
@@ -37,6 +42,10 @@ var (
 type GenericReconciler struct {
 	listLimit             int64
 	watchNamespaces       *watchNamespacesCache
+	scheduler             *namespaceScheduler
+	owners                *ownerResolver
+	sources               *sourceResolver
+	helmReleases          *helmReleases
 	objectValidationCache *validationCache
 	currentObjects        *validationCache
 	client                client.Client
@@ -45,6 +54,13 @@ type GenericReconciler struct {
 	cmWatcher             *configmap.Watcher
 	validationEngine      validations.Interface
 	apiResources          []metav1.APIResource
+	// apiResourcesReady is closed once the API resources have been discovered
+	apiResourcesReady chan struct{}
+	stagedConfig      *stagedConfigPreview
+	revalidate        chan struct{}
+	notifier          *notify.Notifier
+	history           *history.Store
+	remediator        *remediation.AutoRemediator
 }
 
 // NewGenericReconciler returns a GenericReconciler struct
@@ -59,16 +75,46 @@ func NewGenericReconciler(
 		return nil, err
 	}
 
+	namespacesCfg := cmw.CurrentConfig().Namespaces()
+	watchNamespaces, err := newWatchNamespacesCache(namespacesCfg)
+	if err != nil {
+		return nil, fmt.Errorf("initializing watched namespaces: %w", err)
+	}
+
+	interval, err := getValidationInterval()
+	if err != nil {
+		return nil, fmt.Errorf("getting validation interval: %w", err)
+	}
+	scheduler, err := newNamespaceScheduler(interval, namespacesCfg.Tiers)
+	if err != nil {
+		return nil, fmt.Errorf("initializing namespace scheduler: %w", err)
+	}
+
+	logger := ctrl.Log.WithName("GenericReconciler")
+	// the failures are attributed to the top-most controller of the objects
+	owners := newOwnerResolver(client, logger)
+	validationEngine.SetOwnerResolver(owners)
+	// and to the GitOps application they are deployed from
+	sources := newSourceResolver(client, logger)
+	validationEngine.SetSourceResolver(sources)
+
 	return &GenericReconciler{
 		client:                client,
 		discovery:             discovery,
 		listLimit:             listLimit,
-		watchNamespaces:       newWatchNamespacesCache(),
+		watchNamespaces:       watchNamespaces,
+		scheduler:             scheduler,
+		owners:                owners,
+		sources:               sources,
+		helmReleases:          newHelmReleases(cmw.CurrentConfig().Grouping().HelmReleases),
 		objectValidationCache: newValidationCache(),
 		currentObjects:        newValidationCache(),
-		logger:                ctrl.Log.WithName("GenericReconciler"),
+		logger:                logger,
 		cmWatcher:             cmw,
 		validationEngine:      validationEngine,
+		apiResourcesReady:     make(chan struct{}),
+		stagedConfig:          newStagedConfigPreview(stagedConfigPreviewInterval),
+		revalidate:            make(chan struct{}, 1),
 	}, nil
 }
 
@@ -102,24 +148,39 @@ func intFromEnv(envName string) (int, bool, error) {
 	return intVal, true, nil
 }
 
+// SetWatchNamespaces restricts the validation to the given namespaces, as set in WATCH_NAMESPACE.
+// The namespaces are then read one by one instead of being listed, so the operator can run with
+// namespace-scoped Roles only, and the kinds it is not allowed to list are skipped.
+func (gr *GenericReconciler) SetWatchNamespaces(names []string) {
+	gr.watchNamespaces.names = names
+}
+
+// SetArgoCDNamespace sets the namespace Argo CD is installed in, where the Applications
+// whose tracking metadata does not tell their namespace are looked up
+func (gr *GenericReconciler) SetArgoCDNamespace(namespace string) {
+	gr.sources.argoCDNamespace = namespace
+}
+
+// SetNotifier sets the optional notifier of the objects whose validation outcome changed
+func (gr *GenericReconciler) SetNotifier(n *notify.Notifier) {
+	gr.notifier = n
+}
+
 // AddToManager will add the reconciler for the configured obj to a manager.
 func (gr *GenericReconciler) AddToManager(mgr manager.Manager) error {
 	return mgr.Add(gr)
 }
 
-// Start validating the given object kind every interval.
+// Start validating the objects of each namespace at the interval of its tier.
 func (gr *GenericReconciler) Start(ctx context.Context) error {
 	go gr.LookForConfigUpdates(ctx)
 
-	interval, err := getValidationInterval()
-	if err != nil {
-		return err
-	}
-	t := time.NewTicker(interval)
-	err = gr.reconcileEverything(ctx)
+	err := gr.reconcileEverything(ctx)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		gr.logger.Error(err, "error fetching and validating resource types")
 	}
+	// the timer is reset after each reconciliation to the next namespace due
+	t := time.NewTimer(gr.scheduler.wait())
 	for {
 		select {
 		case <-ctx.Done():
@@ -137,19 +198,45 @@ func (gr *GenericReconciler) Start(ctx context.Context) error {
 				gr.logger.Error(err, "error fetching and validating resource types")
 			}
 			gr.logger.Info("Reconciliation loop has ended")
+			t.Reset(gr.scheduler.wait())
+		case <-gr.revalidate:
+			gr.logger.Info("Revalidation after configuration change has started")
+			// cached outcomes are outdated, but metrics are kept until each object is revalidated
+			gr.objectValidationCache.invalidate()
+			gr.scheduler.reset()
+			if err := gr.reconcileEverything(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				gr.logger.Error(err, "error fetching and validating resource types")
+			}
+			gr.logger.Info("Revalidation after configuration change has ended")
+			t.Reset(gr.scheduler.wait())
 		}
 	}
 }
 
+// requestRevalidation asks the reconciliation loop to validate again all the objects
+// as soon as possible. Requests made while one is already pending are coalesced.
+func (gr *GenericReconciler) requestRevalidation() {
+	select {
+	case gr.revalidate <- struct{}{}:
+	default:
+	}
+}
+
 func (gr *GenericReconciler) LookForConfigUpdates(ctx context.Context) {
 	for {
 		select {
-		case <-gr.cmWatcher.ConfigChanged():
-			cfg := gr.cmWatcher.GetConfig()
+		case snapshot := <-gr.cmWatcher.ConfigChanged():
+			cfg := snapshot.Config()
+			previousChecks := gr.validationEngine.GetEnabledChecks()
 			gr.validationEngine.SetConfig(cfg)
+			gr.validationEngine.SetReplicas(snapshot.Replicas())
+			// the parameters of the image checks are only kept along with the registry using them
+			previousImages := gr.validationEngine.GetImages()
+			gr.validationEngine.SetImages(snapshot.Images())
 
 			err := gr.validationEngine.InitRegistry()
 			if err != nil {
+				gr.validationEngine.SetImages(previousImages)
 				gr.logger.Error(
 					err,
 					fmt.Sprintf("error updating configuration from ConfigMap: %v\n", cfg),
@@ -157,14 +244,51 @@ func (gr *GenericReconciler) LookForConfigUpdates(ctx context.Context) {
 				continue
 			}
 
-			gr.objectValidationCache.drain()
-			gr.validationEngine.ResetMetrics()
+			// only the series of checks that are no longer enabled are removed,
+			// the others are replaced when the objects are revalidated
+			gr.validationEngine.ResetMetricsForChecks(
+				removedChecks(previousChecks, gr.validationEngine.GetEnabledChecks()),
+			)
+			if err := gr.watchNamespaces.setFilter(snapshot.Namespaces()); err != nil {
+				gr.logger.Error(err, "error updating namespaces selection, keeping the previous one")
+			}
+			if err := gr.scheduler.setTiers(snapshot.Namespaces().Tiers); err != nil {
+				gr.logger.Error(err, "error updating namespace tiers, keeping the previous ones")
+			}
+			if err := gr.validationEngine.SetOwnership(snapshot.Ownership()); err != nil {
+				gr.logger.Error(err, "error updating ownership, keeping the previous one")
+			}
+			gr.helmReleases.setEnabled(snapshot.Grouping().HelmReleases)
+			gr.requestRevalidation()
 
 			gr.logger.V(1).Info(
 				"Current set of enabled checks",
 				"checks", strings.Join(gr.validationEngine.GetEnabledChecks(), ", "),
 			)
-			gr.logger.Info("The ConfigMap has been updated")
+			if !reflect.DeepEqual(snapshot.MetricLabels(), gr.validationEngine.GetMetricLabels()) {
+				gr.logger.Info("The metric labels configuration has changed, " +
+					"it will only be applied once the operator is restarted")
+			}
+
+			gr.cmWatcher.MarkActive(snapshot)
+			gr.logger.Info("The configuration has been updated",
+				"generation", snapshot.Generation(),
+				"resourceVersion", snapshot.ResourceVersion(),
+			)
+
+			// the staged configuration is now compared against a different active one
+			if staged, ok := gr.cmWatcher.StagedConfig(); ok {
+				gr.startStagedConfigPreview(ctx, staged)
+			}
+
+		case <-gr.cmWatcher.StagedConfigChanged():
+			staged, ok := gr.cmWatcher.StagedConfig()
+			if !ok {
+				gr.stagedConfig.clear()
+				continue
+			}
+
+			gr.startStagedConfigPreview(ctx, staged)
 
 		case <-ctx.Done():
 			return
@@ -172,8 +296,28 @@ func (gr *GenericReconciler) LookForConfigUpdates(ctx context.Context) {
 	}
 }
 
+// removedChecks returns the checks from the previous collection
+// which are not part of the current one
+func removedChecks(previous, current []string) []string {
+	enabled := make(map[string]struct{}, len(current))
+	for _, check := range current {
+		enabled[check] = struct{}{}
+	}
+
+	var removed []string
+	for _, check := range previous {
+		if _, ok := enabled[check]; !ok {
+			removed = append(removed, check)
+		}
+	}
+	return removed
+}
+
 func (gr *GenericReconciler) reconcileEverything(ctx context.Context) error {
 	once.Do(func() {
+		// the API resources are not modified after, and can then be read by the staged
+		// configuration preview, even when their discovery failed
+		defer close(gr.apiResourcesReady)
 		apiResources, err := reconcileResourceList(gr.discovery, gr.client.Scheme())
 		if err != nil {
 			gr.logger.Error(err, "retrieving API resources to reconcile")
@@ -194,22 +338,42 @@ func (gr *GenericReconciler) reconcileEverything(ctx context.Context) error {
 		return fmt.Errorf("getting watched namespaces: %w", err)
 	}
 
+	// the objects of the namespaces which are not due are kept as they are
+	due, skipped := gr.scheduler.due(*namespaces)
+	gr.currentObjects.storeNamespacesKeys(gr.objectValidationCache, skipped)
+	gr.logger.V(1).Info("Namespaces due for validation", "due", len(due), "skipped", len(skipped))
+
 	gvkResources := gr.getNamespacedResourcesGVK(gr.apiResources)
-	errNR := gr.processNamespacedResources(ctx, gvkResources, namespaces)
+	errNR := gr.processNamespacedResources(ctx, gvkResources, &due)
 	if errNR != nil {
 		return fmt.Errorf("processing namespace scoped resources: %w", errNR)
 	}
 
 	gr.handleResourceDeletions()
+	gr.owners.prune()
+	gr.sources.prune()
+	gr.helmReleases.retain(*namespaces)
+	gr.recordHistory(*namespaces)
+	gr.remediate(ctx, *namespaces)
 
 	return nil
 }
 
 // groupAppObjects iterates over provided GroupVersionKind in given namespace
-// and returns map of objects grouped by their "app" label
-func (gr *GenericReconciler) groupAppObjects(ctx context.Context,
-	namespace string, gvks []schema.GroupVersionKind) (map[string][]*unstructured.Unstructured, error) {
+// and returns map of objects grouped by their "app" label. The autoscalers are
+// also part of the groups of the workloads they scale. When enabled, the objects
+// of the Helm releases are grouped by release instead, and the releases are returned
+// along with their objects.
+func (gr *GenericReconciler) groupAppObjects(ctx context.Context, namespace string,
+	gvks []schema.GroupVersionKind) (map[string][]*unstructured.Unstructured, helmReleaseGroups, error) {
 	relatedObjects := make(map[string][]*unstructured.Unstructured)
+	var autoscalers []*unstructured.Unstructured
+
+	releases, err := gr.namespaceReleases(ctx, namespace)
+	if err != nil {
+		return nil, helmReleaseGroups{}, err
+	}
+	releaseObjects := make(map[string][]*unstructured.Unstructured)
 
 	// sorting GVKs is very important for getting the consistent results
 	// when trying to match the 'app' label values. We must be sure that
@@ -234,13 +398,29 @@ func (gr *GenericReconciler) groupAppObjects(ctx context.Context,
 		for {
 
 			if err := gr.client.List(ctx, &list, listOptions); err != nil {
-				return nil, fmt.Errorf("listing %s: %w", gvk.String(), err)
+				if len(gr.watchNamespaces.names) > 0 && apierrors.IsForbidden(err) {
+					gr.logger.V(1).Info("skipping kind not allowed to be listed",
+						"kind", gvk.String(), "namespace", namespace)
+					break
+				}
+				return nil, helmReleaseGroups{}, fmt.Errorf("listing %s: %w", gvk.String(), err)
 			}
 
 			for i := range list.Items {
 				obj := &list.Items[i]
 				unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
 				unstructured.RemoveNestedField(obj.Object, "status")
+				if _, ok := utils.GetScaleTarget(obj); ok {
+					autoscalers = append(autoscalers, obj)
+					if obj.GroupVersionKind().GroupKind() == utils.KEDAScaledObject {
+						// the ScaledObjects are only validated with the workload they scale
+						continue
+					}
+				}
+				if name, ok := releaseOf(releases, obj); ok {
+					releaseObjects[name] = append(releaseObjects[name], obj)
+					continue
+				}
 				processResourceLabels(obj, relatedObjects)
 				gr.processResourceSelectors(obj, relatedObjects)
 			}
@@ -252,7 +432,12 @@ func (gr *GenericReconciler) groupAppObjects(ctx context.Context,
 			listOptions.Continue = listContinue
 		}
 	}
-	return relatedObjects, nil
+	// the groups of the releases are not matched by the selectors of the other objects
+	for name, objects := range releaseObjects {
+		relatedObjects[helmReleaseGroupPrefix+name] = objects
+	}
+	linkScaleTargets(autoscalers, relatedObjects)
+	return relatedObjects, helmReleaseGroups{releases: releases, objects: releaseObjects}, nil
 }
 
 // processResourceLabels reads resource labels and if the labels
@@ -303,53 +488,69 @@ func (gr *GenericReconciler) processNamespacedResources(
 	for _, ns := range *namespaces {
 		logger := gr.logger.WithValues("ns", ns.name).V(1)
 
-		relatedObjects, err := gr.groupAppObjects(ctx, ns.name, gvks)
+		relatedObjects, releases, err := gr.groupAppObjects(ctx, ns.name, gvks)
 		if err != nil {
 			return err
 		}
+		gr.helmReleases.set(ns.name, releases)
+		outcomes := newNamespaceOutcomes()
 		for label, objects := range relatedObjects {
 			logger.Info("Reconciling Namespace Resources",
 				"items", len(objects), "labels", label)
 
-			err := gr.reconcileGroupOfObjects(objects, ns)
+			err := gr.reconcileGroupOfObjects(ctx, objects, ns, outcomes)
 			if err != nil {
+				// the outcomes already cached are not compared again on the next pass
+				gr.notifyTransitions(outcomes, ns)
 				return fmt.Errorf(
 					"reconciling related objects with labels '%s': %w", label, err,
 				)
 			}
 		}
+		gr.notifyTransitions(outcomes, ns)
+		// the namespaces which could not be validated stay due
+		gr.scheduler.schedule(ns)
 	}
 
 	return nil
 }
 
-func (gr *GenericReconciler) reconcileGroupOfObjects(objs []*unstructured.Unstructured, ns namespace) error {
+// reconcileGroupOfObjects validates the group of objects, unless they all have already been
+// validated, and records the outcome of each of them from its own failures
+func (gr *GenericReconciler) reconcileGroupOfObjects(ctx context.Context, objs []*unstructured.Unstructured,
+	ns namespace, outcomes *namespaceOutcomes) error {
 
 	if gr.allObjectsValidated(objs, ns.uid) {
 		gr.logger.V(1).Info("All objects are validated, ending loop", "ns", ns.name)
 		return nil
 	}
 
-	cliObjects := make([]client.Object, 0, len(objs))
-	for _, o := range objs {
-		typedClientObject, err := gr.unstructuredToTyped(o)
-		if err != nil {
-			return fmt.Errorf("instantiating typed object: %w", err)
-		}
-		cliObjects = append(cliObjects, typedClientObject)
+	cliObjects, err := gr.unstructuredListToTyped(objs)
+	if err != nil {
+		return err
 	}
 
-	outcome, err := gr.validationEngine.RunValidationsForObjects(cliObjects, ns.uid)
+	objectOutcomes, err := gr.validationEngine.RunValidationsForObjects(ctx, cliObjects, ns.metadata())
 	if err != nil {
 		return fmt.Errorf("running validations: %w", err)
 	}
 	for _, o := range objs {
-		gr.objectValidationCache.store(o, ns.uid, outcome)
+		outcome, linted := objectOutcomes[string(o.GetUID())]
+		outcomes.record(gr.objectValidationCache, o, ns.uid, outcome, linted)
 	}
 
 	return nil
 }
 
+// notifyTransitions notifies the objects of the namespace whose outcome changed
+func (gr *GenericReconciler) notifyTransitions(outcomes *namespaceOutcomes, ns namespace) {
+	for _, t := range outcomes.transitions() {
+		team := gr.validationEngine.GetTeam(outcomes.objects[t.UID], ns.metadata())
+		t.Team, t.Contact = team.Name, team.Contact
+		gr.notifier.Notify(t)
+	}
+}
+
 // allObjectsValidated checks whether all unstructured objects passed as argument are validated
 // and thus present in the cache
 func (gr *GenericReconciler) allObjectsValidated(objs []*unstructured.Unstructured, namespaceID string) bool {
@@ -365,6 +566,24 @@ func (gr *GenericReconciler) allObjectsValidated(objs []*unstructured.Unstructur
 	return allObjectsValidated
 }
 
+// unstructuredListToTyped converts the group of unstructured objects into typed client objects
+func (gr *GenericReconciler) unstructuredListToTyped(objs []*unstructured.Unstructured) ([]client.Object, error) {
+	cliObjects := make([]client.Object, 0, len(objs))
+	for _, o := range objs {
+		if o.GroupVersionKind().GroupKind() == utils.KEDAScaledObject {
+			// the ScaledObjects are not part of the scheme
+			cliObjects = append(cliObjects, o)
+			continue
+		}
+		typedClientObject, err := gr.unstructuredToTyped(o)
+		if err != nil {
+			return nil, fmt.Errorf("instantiating typed object: %w", err)
+		}
+		cliObjects = append(cliObjects, typedClientObject)
+	}
+	return cliObjects, nil
+}
+
 func (gr *GenericReconciler) unstructuredToTyped(obj *unstructured.Unstructured) (client.Object, error) {
 	typedResource, err := gr.lookUpType(obj)
 	if err != nil {
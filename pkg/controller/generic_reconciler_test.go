@@ -612,7 +612,7 @@ func TestGroupAppObjects(t *testing.T) {
 			// create testing reconciler
 			gr, err := createTestReconciler(nil, tt.objs)
 			assert.NoError(t, err)
-			groupMap, err := gr.groupAppObjects(context.Background(), tt.namespace, tt.gvks)
+			groupMap, _, err := gr.groupAppObjects(context.Background(), tt.namespace, tt.gvks)
 			assert.NoError(t, err)
 
 			for expectedLabel, expectedNames := range tt.expectedNames {
@@ -1022,6 +1022,46 @@ func TestHandleResourceDeletions(t *testing.T) {
 	}
 }
 
+func TestRemovedChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		expected []string
+	}{
+		{
+			name:     "no check removed",
+			previous: []string{"host-ipc", "host-pid"},
+			current:  []string{"host-pid", "host-ipc", "host-network"},
+			expected: nil,
+		},
+		{
+			name:     "checks no longer enabled are returned",
+			previous: []string{"host-ipc", "host-pid", "run-as-non-root"},
+			current:  []string{"host-pid"},
+			expected: []string{"host-ipc", "run-as-non-root"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, removedChecks(tt.previous, tt.current))
+		})
+	}
+}
+
+func TestRequestRevalidation(t *testing.T) {
+	testReconciler, err := createTestReconciler(nil, nil)
+	assert.NoError(t, err)
+
+	// When
+	testReconciler.requestRevalidation()
+	testReconciler.requestRevalidation()
+
+	// Assert
+	assert.Len(t, testReconciler.revalidate, 1, "pending revalidation requests must be coalesced")
+}
+
 func TestListLimit(t *testing.T) {
 	os.Setenv(EnvResorucesPerListQuery, "2")
 	testReconciler, err := createTestReconciler(runtime.NewScheme(), nil)
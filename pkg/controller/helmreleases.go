@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/helm"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// helmReleaseGroupPrefix prefixes the keys of the groups of the Helm releases
+const helmReleaseGroupPrefix = "helm-release:"
+
+// helmReleaseSummary is the compliance summary of a Helm release
+type helmReleaseSummary struct {
+	helm.Release
+	// Objects is the number of objects of the release found in the cluster
+	Objects int `json:"objects"`
+	// FailingObjects is the number of objects failing at least one check
+	FailingObjects int `json:"failingObjects"`
+	// Checks holds the number of objects failing each check
+	Checks map[string]int `json:"checks,omitempty"`
+}
+
+// helmReleaseGroups are the Helm releases of a namespace, by name, along with their objects
+type helmReleaseGroups struct {
+	// releases are nil when the grouping by release is disabled
+	releases map[string]helm.Release
+	objects  map[string][]*unstructured.Unstructured
+}
+
+type trackedRelease struct {
+	release helm.Release
+	// objects are the UIDs of the objects of the release found in the cluster
+	objects map[string]struct{}
+}
+
+// decodedRelease is a release decoded from the given version of its Secret
+type decodedRelease struct {
+	resourceVersion string
+	release         helm.Release
+}
+
+// helmReleases groups the objects of the namespaces by Helm release when enabled, and
+// keeps the releases found for their summary. All its fields are guarded by mux.
+type helmReleases struct {
+	mux     sync.RWMutex
+	enabled bool
+	// releases by namespace and name
+	releases map[string]map[string]trackedRelease
+	// decoded are the releases decoded from their Secret, by namespace and Secret name,
+	// so that a Secret is only decoded again once it changed
+	decoded map[string]map[string]decodedRelease
+}
+
+func newHelmReleases(enabled bool) *helmReleases {
+	return &helmReleases{
+		enabled:  enabled,
+		releases: map[string]map[string]trackedRelease{},
+		decoded:  map[string]map[string]decodedRelease{},
+	}
+}
+
+// setEnabled enables or disables the grouping by Helm release, forgetting the
+// releases found when disabled
+func (h *helmReleases) setEnabled(enabled bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.enabled = enabled
+	if !enabled {
+		h.releases = map[string]map[string]trackedRelease{}
+		h.decoded = map[string]map[string]decodedRelease{}
+	}
+}
+
+func (h *helmReleases) isEnabled() bool {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	return h.enabled
+}
+
+// set replaces the releases of the namespace with the given ones and their objects,
+// unless the grouping by release was disabled when they were read
+func (h *helmReleases) set(namespace string, groups helmReleaseGroups) {
+	if groups.releases == nil {
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if len(groups.releases) == 0 {
+		delete(h.releases, namespace)
+		return
+	}
+
+	tracked := make(map[string]trackedRelease, len(groups.releases))
+	for name, release := range groups.releases {
+		uids := make(map[string]struct{}, len(groups.objects[name]))
+		for _, obj := range groups.objects[name] {
+			uids[string(obj.GetUID())] = struct{}{}
+		}
+		tracked[name] = trackedRelease{release: release, objects: uids}
+	}
+	h.releases[namespace] = tracked
+}
+
+// retain forgets the releases of the namespaces which are no longer validated
+func (h *helmReleases) retain(namespaces []namespace) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	validated := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		validated[ns.name] = struct{}{}
+	}
+	for name := range h.releases {
+		if _, ok := validated[name]; !ok {
+			delete(h.releases, name)
+		}
+	}
+	for name := range h.decoded {
+		if _, ok := validated[name]; !ok {
+			delete(h.decoded, name)
+		}
+	}
+}
+
+// decode returns the releases of the given Secrets of the namespace, only decoding the
+// Secrets whose resourceVersion changed since they were last decoded. The Secrets which
+// cannot be decoded are skipped, and their errors returned.
+func (h *helmReleases) decode(namespace string, secrets []corev1.Secret) ([]helm.Release, error) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	previous := h.decoded[namespace]
+	decoded := make(map[string]decodedRelease, len(secrets))
+	releases := make([]helm.Release, 0, len(secrets))
+	var errs []error
+	for i := range secrets {
+		secret := &secrets[i]
+		d, ok := previous[secret.Name]
+		if !ok || d.resourceVersion != secret.ResourceVersion {
+			release, err := helm.DecodeRelease(secret)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			d = decodedRelease{resourceVersion: secret.ResourceVersion, release: release}
+		}
+		decoded[secret.Name] = d
+		releases = append(releases, d.release)
+	}
+	// the Secrets deleted are forgotten
+	h.decoded[namespace] = decoded
+	return releases, errors.Join(errs...)
+}
+
+// summarize returns the compliance summary of the releases found, counting
+// the failing objects from the given results
+func (h *helmReleases) summarize(results []validations.CheckResult) []helmReleaseSummary {
+	failing := map[string]map[string]struct{}{}
+	for _, r := range results {
+		if _, ok := failing[r.UID]; !ok {
+			failing[r.UID] = map[string]struct{}{}
+		}
+		failing[r.UID][r.Check] = struct{}{}
+	}
+
+	h.mux.RLock()
+	summaries := []helmReleaseSummary{}
+	for _, releases := range h.releases {
+		for _, tracked := range releases {
+			summary := helmReleaseSummary{
+				Release: tracked.release,
+				Objects: len(tracked.objects),
+				Checks:  map[string]int{},
+			}
+			for uid := range tracked.objects {
+				checks, ok := failing[uid]
+				if !ok {
+					continue
+				}
+				summary.FailingObjects++
+				for check := range checks {
+					summary.Checks[check]++
+				}
+			}
+			summaries = append(summaries, summary)
+		}
+	}
+	h.mux.RUnlock()
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Namespace != summaries[j].Namespace {
+			return summaries[i].Namespace < summaries[j].Namespace
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// namespaceReleases returns the deployed Helm releases of the namespace by name, read from
+// their release Secrets, nil when the grouping by release is disabled or the Secrets cannot
+// be listed. The Secrets which cannot be decoded are skipped.
+func (gr *GenericReconciler) namespaceReleases(ctx context.Context, namespace string) (map[string]helm.Release, error) {
+	if !gr.helmReleases.isEnabled() {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(helm.ReleaseSecretSelector)
+	if err != nil {
+		return nil, err
+	}
+	secrets := corev1.SecretList{}
+	err = gr.client.List(ctx, &secrets, &client.ListOptions{Namespace: namespace, LabelSelector: selector})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			gr.logger.V(1).Info("skipping Helm releases not allowed to be listed", "namespace", namespace)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing Helm releases: %w", err)
+	}
+
+	releases, err := gr.helmReleases.decode(namespace, secrets.Items)
+	if err != nil {
+		gr.logger.Error(err, "decoding Helm releases", "namespace", namespace)
+	}
+	return helm.Latest(releases), nil
+}
+
+// releaseOf returns the name of the release the object is part of, if any
+func releaseOf(releases map[string]helm.Release, obj *unstructured.Unstructured) (string, bool) {
+	gk := obj.GroupVersionKind().GroupKind()
+	for name, release := range releases {
+		if release.Contains(gk, obj.GetNamespace(), obj.GetName()) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// HelmReleasesHandler returns an http.Handler serving, as JSON, the compliance summary
+// of the Helm releases, when the objects are grouped by release
+func (gr *GenericReconciler) HelmReleasesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		summaries := gr.helmReleases.summarize(gr.validationEngine.GetResults())
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summaries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
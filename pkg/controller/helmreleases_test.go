@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/helm"
+	"github.com/app-sre/deployment-validation-operator/pkg/testutils"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+func TestGroupAppObjectsByHelmRelease(t *testing.T) {
+	// Given
+	release, err := testutils.NewHelmReleaseSecret("test", "sh.helm.release.v1.payments.v2", `{
+		"name": "payments", "namespace": "test", "version": 2,
+		"info": {"status": "deployed"},
+		"chart": {"metadata": {"name": "payments", "version": "1.2.0"}},
+		"manifest": "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: payments\n---\n`+
+		`apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: payments\n"
+	}`, true)
+	assert.NoError(t, err)
+	objects := []client.Object{
+		release,
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name: "payments", Namespace: "test", UID: "service",
+			Labels: map[string]string{"app.kubernetes.io/instance": "payments"},
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name: "payments", Namespace: "test", UID: "deployment",
+			Labels: map[string]string{"app.kubernetes.io/instance": "payments", "app": "payments"},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "other", Namespace: "test", UID: "pod",
+			Labels: map[string]string{"app": "other"},
+		}},
+	}
+	gvks := []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Service"},
+		{Version: "v1", Kind: "Pod"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	}
+	gr, err := createTestReconciler(nil, objects)
+	assert.NoError(t, err)
+
+	// When
+	groups, releases, err := gr.groupAppObjects(context.Background(), "test", gvks)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotContains(t, groups, "helm-release:payments", "the grouping by release is disabled by default")
+	assert.Nil(t, releases.releases)
+
+	// When
+	gr.helmReleases.setEnabled(true)
+	groups, releases, err = gr.groupAppObjects(context.Background(), "test", gvks)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, gr.helmReleases.summarize(nil), "the releases are only kept once set")
+	assert.ElementsMatch(t, []string{"payments", "payments"}, unstructuredToNames(groups["helm-release:payments"]))
+	assert.Equal(t, []string{"other"}, unstructuredToNames(groups["app=other"]))
+	assert.Len(t, groups, 2)
+
+	// When
+	gr.helmReleases.set("test", releases)
+
+	// Assert
+	summaries := gr.helmReleases.summarize([]validations.CheckResult{
+		{Failure: validations.Failure{Check: "no-liveness-probe", UID: "deployment"}},
+		{Failure: validations.Failure{Check: "no-readiness-probe", UID: "deployment"}},
+		{Failure: validations.Failure{Check: "no-liveness-probe", UID: "pod"}},
+	})
+	assert.Equal(t, []helmReleaseSummary{{
+		Release: helm.Release{
+			Name: "payments", Namespace: "test", Revision: 2, Status: helm.StatusDeployed,
+			Chart: "payments", ChartVersion: "1.2.0",
+			Resources: []helm.Resource{
+				{GroupKind: schema.GroupKind{Kind: "Service"}, Name: "payments"},
+				{GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"}, Name: "payments"},
+			},
+		},
+		Objects:        2,
+		FailingObjects: 1,
+		Checks:         map[string]int{"no-liveness-probe": 1, "no-readiness-probe": 1},
+	}}, summaries)
+
+	// When
+	gr.helmReleases.retain([]namespace{{name: "other"}})
+
+	// Assert
+	assert.Empty(t, gr.helmReleases.summarize(nil))
+}
+
+func TestHelmReleasesDecode(t *testing.T) {
+	// Given
+	newSecret := func(name, release, resourceVersion string) corev1.Secret {
+		secret, err := testutils.NewHelmReleaseSecret("test", name, release, true)
+		assert.NoError(t, err)
+		secret.ResourceVersion = resourceVersion
+		return *secret
+	}
+	v1 := newSecret("sh.helm.release.v1.payments.v1", `{"name": "payments", "version": 1}`, "1")
+	h := newHelmReleases(true)
+	releases, err := h.decode("test", []corev1.Secret{v1})
+	assert.NoError(t, err)
+	assert.Equal(t, []helm.Release{{Name: "payments", Revision: 1}}, releases)
+
+	// When
+	unchanged := newSecret(v1.Name, `{"name": "payments", "version": 2}`, "1")
+	invalid := newSecret("sh.helm.release.v1.invalid.v1", `{`, "1")
+	releases, err = h.decode("test", []corev1.Secret{unchanged, invalid})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, []helm.Release{{Name: "payments", Revision: 1}}, releases,
+		"the Secrets whose resourceVersion did not change are not decoded again")
+
+	// When
+	changed := newSecret(v1.Name, `{"name": "payments", "version": 2}`, "2")
+	releases, err = h.decode("test", []corev1.Secret{changed})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []helm.Release{{Name: "payments", Revision: 2}}, releases)
+	assert.Len(t, h.decoded["test"], 1, "the Secrets deleted are forgotten")
+
+	// When
+	h.retain(nil)
+
+	// Assert
+	assert.Empty(t, h.decoded)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/history"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// SetHistory sets the optional store recording a daily summary of each namespace
+func (gr *GenericReconciler) SetHistory(s *history.Store) {
+	gr.history = s
+}
+
+// recordHistory records the summary of the given namespaces at the end of a reconciliation,
+// along with the time each check was first seen failing, so that it survives a restart
+func (gr *GenericReconciler) recordHistory(namespaces []namespace) {
+	if gr.history == nil {
+		return
+	}
+
+	results := gr.validationEngine.GetResults()
+	summaries := summarizeNamespaces(namespaces, gr.objectValidationCache, results)
+	if err := gr.history.Record(summaries); err != nil {
+		gr.logger.Error(err, "recording validation history")
+	}
+	if err := gr.history.RecordFailingSince(failingSince(results)); err != nil {
+		gr.logger.Error(err, "recording failing since times")
+	}
+}
+
+// failingSince returns the time each check was first seen failing, by object UID and check name
+func failingSince(results []validations.CheckResult) map[string]map[string]time.Time {
+	times := map[string]map[string]time.Time{}
+	for _, result := range results {
+		if _, ok := times[result.UID]; !ok {
+			times[result.UID] = map[string]time.Time{}
+		}
+		times[result.UID][result.Check] = result.FailingSince
+	}
+	return times
+}
+
+// summarizeNamespaces returns the summary of each of the given namespaces,
+// counting the validated objects from the cache and the failing ones from the results.
+// An object is counted once per check, which reports a failure per container.
+func summarizeNamespaces(namespaces []namespace, cache *validationCache,
+	results []validations.CheckResult) []history.NamespaceSummary {
+	summaries := make([]history.NamespaceSummary, 0, len(namespaces))
+	index := make(map[string]int, len(namespaces))
+	for i, ns := range namespaces {
+		index[ns.name] = i
+		summaries = append(summaries, history.NamespaceSummary{Namespace: ns.name, Checks: map[string]int{}})
+	}
+
+	for key := range *cache {
+		if i, ok := index[key.namespace]; ok {
+			summaries[i].Objects++
+		}
+	}
+
+	type objectCheck struct{ uid, check string }
+	failing := map[string]struct{}{}
+	failingChecks := map[objectCheck]struct{}{}
+	for _, result := range results {
+		i, ok := index[result.Namespace]
+		if !ok {
+			continue
+		}
+		if _, ok := failingChecks[objectCheck{result.UID, result.Check}]; !ok {
+			failingChecks[objectCheck{result.UID, result.Check}] = struct{}{}
+			summaries[i].Checks[result.Check]++
+		}
+		if _, ok := failing[result.UID]; !ok {
+			failing[result.UID] = struct{}{}
+			summaries[i].FailingObjects++
+		}
+	}
+
+	return summaries
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/history"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeNamespaces(t *testing.T) {
+	// Given
+	namespaces := []namespace{{uid: "uid-a", name: "ns-a"}, {uid: "uid-b", name: "ns-b"}}
+	cache := newValidationCache()
+	(*cache)[validationKey{name: "app", namespace: "ns-a", uid: "app"}] = &validationResource{}
+	(*cache)[validationKey{name: "svc", namespace: "ns-a", uid: "svc"}] = &validationResource{}
+	(*cache)[validationKey{name: "pod", namespace: "ns-a", uid: "pod"}] = &validationResource{}
+	(*cache)[validationKey{name: "other", namespace: "ignored", uid: "other"}] = &validationResource{}
+	failure := func(namespace, uid, check, container string) validations.CheckResult {
+		return validations.CheckResult{
+			Failure: validations.Failure{
+				Namespace: namespace, UID: uid, Check: check, Container: container,
+			},
+		}
+	}
+	results := []validations.CheckResult{
+		failure("ns-a", "app", "check-a", ""),
+		failure("ns-a", "app", "check-b", ""),
+		// a failure per container of the object
+		failure("ns-a", "pod", "check-a", "first"),
+		failure("ns-a", "pod", "check-a", "second"),
+		failure("ignored", "other", "check-a", ""),
+	}
+
+	// When
+	summaries := summarizeNamespaces(namespaces, cache, results)
+
+	// Assert
+	assert.Equal(t, []history.NamespaceSummary{
+		{Namespace: "ns-a", Objects: 3, FailingObjects: 2, Checks: map[string]int{"check-a": 2, "check-b": 1}},
+		{Namespace: "ns-b", Checks: map[string]int{}},
+	}, summaries)
+}
+
+func TestFailingSince(t *testing.T) {
+	// Given
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	failure := func(uid, check, container string) validations.CheckResult {
+		return validations.CheckResult{
+			Failure:      validations.Failure{UID: uid, Check: check, Container: container},
+			FailingSince: since,
+		}
+	}
+	results := []validations.CheckResult{
+		failure("app", "check-a", "nginx"),
+		failure("app", "check-a", "sidecar"),
+		failure("app", "check-b", ""),
+		failure("other", "check-a", ""),
+	}
+
+	// When
+	times := failingSince(results)
+
+	// Assert
+	assert.Equal(t, map[string]map[string]time.Time{
+		"app":   {"check-a": since, "check-b": since},
+		"other": {"check-a": since},
+	}, times)
+}
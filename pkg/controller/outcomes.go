@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/notify"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// namespaceOutcomes accumulates the outcomes of the objects of the groups of a namespace
+// validated in a pass, so their transitions are only notified once all their groups have
+// been validated. An object part of several groups needs improvement if it does in any of
+// them, and the objects which are not linted, e.g. owned by a workload, are never notified.
+type namespaceOutcomes struct {
+	// uids are the UIDs of the objects, in the order they were first recorded
+	uids    []string
+	objects map[string]*unstructured.Unstructured
+	// previous are the outcomes cached before the pass, if any, by UID
+	previous map[string]validations.ValidationOutcome
+	// current are the outcomes of the objects over the groups validated so far, by UID
+	current map[string]validations.ValidationOutcome
+}
+
+func newNamespaceOutcomes() *namespaceOutcomes {
+	return &namespaceOutcomes{
+		objects:  map[string]*unstructured.Unstructured{},
+		previous: map[string]validations.ValidationOutcome{},
+		current:  map[string]validations.ValidationOutcome{},
+	}
+}
+
+// record stores in the cache the outcome of the object in a group, combined with its
+// outcomes in the groups previously validated in the pass. The outcome of the objects
+// which are not linted is ObjectValidationIgnored.
+func (n *namespaceOutcomes) record(cache *validationCache, obj *unstructured.Unstructured, nsUID string,
+	outcome validations.ValidationOutcome, linted bool) {
+	uid := string(obj.GetUID())
+	current, seen := n.current[uid]
+	switch {
+	case !linted && seen:
+		outcome = current
+	case !linted:
+		outcome = validations.ObjectValidationIgnored
+	case current == validations.ObjectNeedsImprovement:
+		outcome = current
+	}
+
+	previous, cached := cache.store(obj, nsUID, outcome)
+	if !seen {
+		n.uids = append(n.uids, uid)
+		n.objects[uid] = obj
+		if cached {
+			n.previous[uid] = previous
+		}
+	}
+	n.current[uid] = outcome
+}
+
+// transitions returns the transitions of the objects whose outcome changed during the pass
+func (n *namespaceOutcomes) transitions() []notify.Transition {
+	var transitions []notify.Transition
+	for _, uid := range n.uids {
+		previous, ok := n.previous[uid]
+		current := n.current[uid]
+		if ok && notify.IsTransition(previous, current) {
+			transitions = append(transitions, notify.NewTransition(n.objects[uid], previous, current))
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+func TestNamespaceOutcomes(t *testing.T) {
+	newObject := func(kind, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind(kind)
+		obj.SetNamespace("ns")
+		obj.SetName(name)
+		obj.SetUID(types.UID(kind + "-" + name))
+		obj.SetResourceVersion("1")
+		return obj
+	}
+	deployment := newObject("Deployment", "app")
+	service := newObject("Service", "app")
+	shared := newObject("ConfigMap", "shared")
+
+	// Given
+	cache := newValidationCache()
+	cache.store(deployment, "ns-uid", validations.ObjectValid)
+	cache.store(service, "ns-uid", validations.ObjectValid)
+	cache.store(shared, "ns-uid", validations.ObjectNeedsImprovement)
+	outcomes := newNamespaceOutcomes()
+
+	// When
+	outcomes.record(cache, deployment, "ns-uid", validations.ObjectNeedsImprovement, true)
+	outcomes.record(cache, service, "ns-uid", "", false)
+	outcomes.record(cache, shared, "ns-uid", validations.ObjectNeedsImprovement, true)
+	outcomes.record(cache, shared, "ns-uid", validations.ObjectValid, true)
+
+	// Assert
+	transitions := outcomes.transitions()
+	assert.Len(t, transitions, 1, "only the deployment failing on its own is notified")
+	assert.Equal(t, "Deployment", transitions[0].Kind)
+	assert.Equal(t, validations.ObjectNeedsImprovement, transitions[0].To)
+
+	cached, ok := cache.retrieve(service, "ns-uid")
+	assert.True(t, ok)
+	assert.Equal(t, validations.ObjectValidationIgnored, cached.outcome)
+	cached, ok = cache.retrieve(shared, "ns-uid")
+	assert.True(t, ok)
+	assert.Equal(t, validations.ObjectNeedsImprovement, cached.outcome,
+		"the object needs improvement in one of its groups")
+}
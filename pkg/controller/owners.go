@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+const (
+	// ownerCacheTTL is the time the controller of an owner is cached for,
+	// as the owner references of an object may change
+	ownerCacheTTL = 10 * time.Minute
+	// maxOwnerDepth bounds the chains of owners, in case of a cycle
+	maxOwnerDepth = 10
+)
+
+type cachedOwner struct {
+	// controller is the controller of the owner, nil if it has none
+	// or if the owner could not be read
+	controller *metav1.OwnerReference
+	fetched    time.Time
+	// used tells whether the owner has been used since the last pruning
+	used bool
+}
+
+// ownerResolver resolves the chains of the controllers of the objects, following
+// their controller owner references. The owners are read from the API, only their
+// metadata, and cached by UID. The cache is guarded by mux, which is not held
+// while reading from the API.
+type ownerResolver struct {
+	mux    sync.Mutex
+	client client.Client
+	logger logr.Logger
+	cache  map[types.UID]*cachedOwner
+	now    func() time.Time
+}
+
+func newOwnerResolver(c client.Client, logger logr.Logger) *ownerResolver {
+	return &ownerResolver{
+		client: c,
+		logger: logger,
+		cache:  map[types.UID]*cachedOwner{},
+		now:    time.Now,
+	}
+}
+
+// Owners returns the chain of the controllers of the given object, from its controller to
+// the top-most one. The chain stops at the owners which cannot be read or no longer exist.
+// Only the objects with a controller are resolved.
+func (r *ownerResolver) Owners(ctx context.Context, obj client.Object) []validations.Owner {
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil {
+		return nil
+	}
+
+	var owners []validations.Owner
+	visited := map[types.UID]struct{}{obj.GetUID(): {}}
+	for ref != nil && len(owners) < maxOwnerDepth {
+		if _, ok := visited[ref.UID]; ok {
+			break
+		}
+		visited[ref.UID] = struct{}{}
+
+		owners = append(owners, validations.Owner{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			UID:        string(ref.UID),
+		})
+		ref = r.controllerOf(ctx, obj.GetNamespace(), *ref)
+	}
+	return owners
+}
+
+// cached returns the controller of the given owner from the cache, if it has not expired
+func (r *ownerResolver) cached(uid types.UID) (*metav1.OwnerReference, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	cached, ok := r.cache[uid]
+	if !ok || r.now().Sub(cached.fetched) >= ownerCacheTTL {
+		return nil, false
+	}
+	cached.used = true
+	return cached.controller, true
+}
+
+// controllerOf returns the controller of the given owner, from the cache or from the API
+func (r *ownerResolver) controllerOf(ctx context.Context, namespace string,
+	ref metav1.OwnerReference) *metav1.OwnerReference {
+	if controller, ok := r.cached(ref.UID); ok {
+		return controller
+	}
+
+	entry := &cachedOwner{fetched: r.now(), used: true}
+	owner := &metav1.PartialObjectMetadata{}
+	owner.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	// the namespace is ignored for the cluster-scoped owners
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, owner)
+	switch {
+	case ctx.Err() != nil:
+		// the owner is read again by the next reconciliation
+		return nil
+	case err != nil:
+		if !apierrors.IsNotFound(err) && !apierrors.IsForbidden(err) {
+			r.logger.Error(err, "reading owner", "kind", ref.Kind, "namespace", namespace, "name", ref.Name)
+		}
+	case owner.GetUID() == ref.UID:
+		entry.controller = metav1.GetControllerOf(owner)
+	}
+	// an owner replaced by another one with the same name ends the chain
+	r.mux.Lock()
+	r.cache[ref.UID] = entry
+	r.mux.Unlock()
+	return entry.controller
+}
+
+// prune forgets the owners which have not been used since the previous pruning
+func (r *ownerResolver) prune() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for uid, cached := range r.cache {
+		if !cached.used {
+			delete(r.cache, uid)
+			continue
+		}
+		cached.used = false
+	}
+}
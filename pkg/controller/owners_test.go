@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+func controllerRef(apiVersion, kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{
+		APIVersion: apiVersion, Kind: kind, Name: name, UID: types.UID(name), Controller: &controller,
+	}}
+}
+
+func TestOwnerResolver(t *testing.T) {
+	// Given
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{
+		Name: "cronjob", Namespace: "test", UID: "cronjob",
+	}}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name: "job", Namespace: "test", UID: "job",
+		OwnerReferences: controllerRef("batch/v1", "CronJob", "cronjob"),
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "pod", Namespace: "test", UID: "pod",
+		OwnerReferences: controllerRef("batch/v1", "Job", "job"),
+	}}
+	orphan := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "orphan", Namespace: "test", UID: "orphan",
+		OwnerReferences: controllerRef("batch/v1", "Job", "deleted"),
+	}}
+
+	gets := 0
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cronJob, job).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey,
+				obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	r := newOwnerResolver(c, logr.Discard())
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// When
+	owners := r.Owners(ctx, pod)
+
+	// Assert
+	assert.Equal(t, []validations.Owner{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "job", UID: "job"},
+		{APIVersion: "batch/v1", Kind: "CronJob", Name: "cronjob", UID: "cronjob"},
+	}, owners)
+	assert.Equal(t, 2, gets)
+
+	assert.Equal(t, owners, r.Owners(ctx, pod))
+	assert.Equal(t, 2, gets, "the owners are cached")
+
+	assert.Equal(t, []validations.Owner{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "deleted", UID: "deleted"},
+	}, r.Owners(ctx, orphan), "the chain stops at the owners not found")
+	assert.Equal(t, 3, gets)
+
+	// When
+	r.prune()
+	r.Owners(ctx, pod)
+	r.prune()
+
+	// Assert
+	assert.Len(t, r.cache, 2, "the owners not used since the previous pruning are forgotten")
+
+	// When
+	now = now.Add(ownerCacheTTL)
+	r.Owners(ctx, pod)
+
+	// Assert
+	assert.Equal(t, 5, gets, "the owners are read again once expired")
+	assert.Empty(t, r.Owners(ctx, cronJob))
+	assert.Equal(t, 5, gets, "the objects without controller are not resolved")
+
+	// When
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	now = now.Add(ownerCacheTTL)
+	r.Owners(cancelled, pod)
+	r.Owners(ctx, pod)
+
+	// Assert
+	assert.Equal(t, 8, gets, "the owners read after the cancellation are not cached")
+}
@@ -9,6 +9,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
 )
 
 type resourceSet struct {
@@ -33,14 +35,19 @@ func (s *resourceSet) Add(key schema.GroupKind, val metav1.APIResource) error {
 		return nil
 	}
 
-	if ok, err := isRegisteredKubeLinterKind(val); err != nil {
-		return fmt.Errorf("checking if resource %s, is registered KubeLinter kind: %w", val.String(), err)
-	} else if !ok {
-		return nil
-	}
+	// the KEDA ScaledObjects are unknown to kube-linter and to the scheme,
+	// but are listed to be linked to the workloads they scale
+	if key != utils.KEDAScaledObject {
+		if ok, err := isRegisteredKubeLinterKind(val); err != nil {
+			return fmt.Errorf("checking if resource %s, is registered KubeLinter kind: %w",
+				val.String(), err)
+		} else if !ok {
+			return nil
+		}
 
-	if !s.scheme.Recognizes(gvkFromMetav1APIResource(val)) {
-		return nil
+		if !s.scheme.Recognizes(gvkFromMetav1APIResource(val)) {
+			return nil
+		}
 	}
 
 	if existing, ok := s.apiResources[key]; ok {
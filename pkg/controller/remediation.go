@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/remediation"
+)
+
+// SetRemediator sets the optional remediator patching the objects failing the allowed checks
+func (gr *GenericReconciler) SetRemediator(r *remediation.AutoRemediator) {
+	gr.remediator = r
+}
+
+// remediate remediates the failures of the objects in the given namespaces
+// at the end of a reconciliation
+func (gr *GenericReconciler) remediate(ctx context.Context, namespaces []namespace) {
+	if gr.remediator == nil {
+		return
+	}
+
+	namespaceLabels := make(map[string]map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		namespaceLabels[ns.name] = ns.labels
+	}
+	gr.remediator.Remediate(ctx, gr.validationEngine.GetResults(), namespaceLabels)
+}
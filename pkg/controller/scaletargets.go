@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
+)
+
+// linkScaleTargets adds the autoscalers, HorizontalPodAutoscalers and KEDA ScaledObjects, to the
+// groups of the workloads they scale, as they have no selector. They are then validated along
+// with their workload, so the checks of the replicas account for them.
+func linkScaleTargets(autoscalers []*unstructured.Unstructured,
+	relatedObjects map[string][]*unstructured.Unstructured) {
+	for _, autoscaler := range autoscalers {
+		target, ok := utils.GetScaleTarget(autoscaler)
+		if !ok {
+			continue
+		}
+		for key, objs := range relatedObjects {
+			if containsScaleTarget(objs, target) && !containsObject(objs, autoscaler) {
+				relatedObjects[key] = append(objs, autoscaler)
+			}
+		}
+	}
+}
+
+func containsScaleTarget(objs []*unstructured.Unstructured, target utils.ScaleTarget) bool {
+	for _, obj := range objs {
+		if obj.GetKind() == target.Kind && obj.GetName() == target.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsObject(objs []*unstructured.Unstructured, obj *unstructured.Unstructured) bool {
+	for _, o := range objs {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLinkScaleTargets(t *testing.T) {
+	object := func(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       spec,
+		}}
+	}
+	scaleTargetRef := func(kind, name string) map[string]interface{} {
+		ref := map[string]interface{}{"name": name}
+		if kind != "" {
+			ref["kind"] = kind
+		}
+		return map[string]interface{}{"scaleTargetRef": ref}
+	}
+
+	// Given
+	app := object("apps/v1", "Deployment", "app", nil)
+	service := object("v1", "Service", "app", nil)
+	worker := object("apps/v1", "StatefulSet", "worker", nil)
+	hpa := object("autoscaling/v2", "HorizontalPodAutoscaler", "app", scaleTargetRef("Deployment", "app"))
+	scaledObject := object("keda.sh/v1alpha1", "ScaledObject", "worker", scaleTargetRef("", "worker"))
+	relatedObjects := map[string][]*unstructured.Unstructured{
+		"app=app":    {app, service, hpa},
+		"app=worker": {worker},
+	}
+
+	// When
+	linkScaleTargets([]*unstructured.Unstructured{hpa, scaledObject}, relatedObjects)
+
+	// Assert
+	assert.Equal(t, []*unstructured.Unstructured{app, service, hpa}, relatedObjects["app=app"],
+		"the HPA already grouped by its labels is not added again")
+	assert.Equal(t, []*unstructured.Unstructured{worker}, relatedObjects["app=worker"],
+		"the ScaledObjects scale Deployments unless set otherwise")
+
+	// When
+	scaledObject = object("keda.sh/v1alpha1", "ScaledObject", "worker", scaleTargetRef("StatefulSet", "worker"))
+	linkScaleTargets([]*unstructured.Unstructured{scaledObject}, relatedObjects)
+
+	// Assert
+	assert.Equal(t, []*unstructured.Unstructured{worker, scaledObject}, relatedObjects["app=worker"])
+}
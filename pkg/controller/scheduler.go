@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+)
+
+type namespaceTier struct {
+	name     string
+	selector labels.Selector
+	interval time.Duration
+}
+
+// namespaceScheduler keeps the time each namespace is next validated at,
+// according to the interval of its tier. All its fields are guarded by mux.
+type namespaceScheduler struct {
+	mux             sync.Mutex
+	defaultInterval time.Duration
+	tiers           []namespaceTier
+	// next is the time of the next validation, by namespace name
+	next map[string]time.Time
+	now  func() time.Time
+}
+
+// newNamespaceScheduler returns a scheduler validating the namespaces at the given
+// default interval, unless set otherwise by the tiers
+func newNamespaceScheduler(defaultInterval time.Duration,
+	tiers []configmap.NamespaceTier) (*namespaceScheduler, error) {
+	s := &namespaceScheduler{
+		defaultInterval: defaultInterval,
+		next:            map[string]time.Time{},
+		now:             time.Now,
+	}
+	if err := s.setTiers(tiers); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// setTiers replaces the tiers, applied from the next validation of each namespace.
+// The current tiers are kept if the given ones are not valid.
+func (s *namespaceScheduler) setTiers(cfg []configmap.NamespaceTier) error {
+	tiers := make([]namespaceTier, 0, len(cfg))
+	for _, tier := range cfg {
+		selector, err := labels.Parse(tier.Selector)
+		if err != nil {
+			return fmt.Errorf("parsing selector of namespace tier %q: %w", tier.Name, err)
+		}
+		if tier.Interval.Duration <= 0 {
+			return fmt.Errorf("interval of namespace tier %q must be positive", tier.Name)
+		}
+		tiers = append(tiers, namespaceTier{
+			name:     tier.Name,
+			selector: selector,
+			interval: tier.Interval.Duration,
+		})
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.tiers = tiers
+	return nil
+}
+
+// interval returns the validation interval of the given namespace.
+// The caller must hold the lock.
+func (s *namespaceScheduler) interval(ns namespace) time.Duration {
+	for _, tier := range s.tiers {
+		if tier.selector.Matches(labels.Set(ns.labels)) {
+			return tier.interval
+		}
+	}
+	return s.defaultInterval
+}
+
+// due splits the given namespaces between the ones to validate now and the others.
+// The namespaces due stay so until their next validation is scheduled, once they
+// have been validated. The namespaces not given are forgotten.
+func (s *namespaceScheduler) due(namespaces []namespace) (due, skipped []namespace) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.now()
+	next := make(map[string]time.Time, len(namespaces))
+	for _, ns := range namespaces {
+		if at, ok := s.next[ns.name]; ok && now.Before(at) {
+			next[ns.name] = at
+			skipped = append(skipped, ns)
+			continue
+		}
+		due = append(due, ns)
+	}
+	s.next = next
+
+	return due, skipped
+}
+
+// schedule schedules the next validation of the given namespace, which has just been validated
+func (s *namespaceScheduler) schedule(ns namespace) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.next[ns.name] = s.now().Add(s.interval(ns))
+}
+
+// wait returns the time until the next namespace is due. It is at most the
+// default interval, so the new namespaces, and the ones whose validation
+// failed, are validated within that time.
+func (s *namespaceScheduler) wait() time.Duration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	wait := s.defaultInterval
+	now := s.now()
+	for _, at := range s.next {
+		if d := at.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// reset makes all the namespaces due, e.g. after a configuration change
+func (s *namespaceScheduler) reset() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.next = map[string]time.Time{}
+}
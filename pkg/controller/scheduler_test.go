@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+)
+
+func TestNamespaceScheduler(t *testing.T) {
+	prod := namespace{name: "prod", labels: map[string]string{"env": "production"}}
+	dev := namespace{name: "dev", labels: map[string]string{"env": "dev"}}
+	other := namespace{name: "other"}
+	tiers := []configmap.NamespaceTier{
+		{Name: "production", Selector: "env=production", Interval: metav1.Duration{Duration: 2 * time.Minute}},
+		{Name: "dev", Selector: "env=dev", Interval: metav1.Duration{Duration: time.Hour}},
+	}
+
+	newTestScheduler := func(t *testing.T, now *time.Time) *namespaceScheduler {
+		s, err := newNamespaceScheduler(10*time.Minute, tiers)
+		assert.NoError(t, err)
+		s.now = func() time.Time { return *now }
+		return s
+	}
+	// validate returns the namespaces due, and schedules their next validation
+	validate := func(s *namespaceScheduler, namespaces []namespace) ([]namespace, []namespace) {
+		due, skipped := s.due(namespaces)
+		for _, ns := range due {
+			s.schedule(ns)
+		}
+		return due, skipped
+	}
+
+	t.Run("the namespaces are due at the interval of their tier", func(t *testing.T) {
+		// Given
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := newTestScheduler(t, &now)
+		namespaces := []namespace{prod, dev, other}
+
+		// When
+		firstDue, _ := validate(s, namespaces)
+		firstWait := s.wait()
+		now = now.Add(2 * time.Minute)
+		secondDue, secondSkipped := validate(s, namespaces)
+		now = now.Add(8 * time.Minute)
+		thirdDue, _ := validate(s, namespaces)
+
+		// Assert
+		assert.Equal(t, namespaces, firstDue)
+		assert.Equal(t, 2*time.Minute, firstWait)
+		assert.Equal(t, []namespace{prod}, secondDue)
+		assert.Equal(t, []namespace{dev, other}, secondSkipped)
+		assert.Equal(t, []namespace{prod, other}, thirdDue)
+	})
+
+	t.Run("the wait is bounded by the default interval", func(t *testing.T) {
+		// Given
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := newTestScheduler(t, &now)
+
+		// When
+		_, _ = validate(s, []namespace{dev})
+		waitDev := s.wait()
+		now = now.Add(2 * time.Hour)
+		waitLate := s.wait()
+
+		// Assert
+		assert.Equal(t, 10*time.Minute, waitDev)
+		assert.Equal(t, time.Duration(0), waitLate)
+	})
+
+	t.Run("reset makes all the namespaces due", func(t *testing.T) {
+		// Given
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := newTestScheduler(t, &now)
+		_, _ = validate(s, []namespace{prod, dev})
+
+		// When
+		s.reset()
+		due, skipped := validate(s, []namespace{prod, dev})
+
+		// Assert
+		assert.Equal(t, []namespace{prod, dev}, due)
+		assert.Empty(t, skipped)
+	})
+
+	t.Run("invalid tiers are rejected and the current ones kept", func(t *testing.T) {
+		// Given
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := newTestScheduler(t, &now)
+
+		// When
+		errSelector := s.setTiers([]configmap.NamespaceTier{
+			{Name: "broken", Selector: "env in (", Interval: metav1.Duration{Duration: time.Minute}},
+		})
+		errInterval := s.setTiers([]configmap.NamespaceTier{{Name: "zero"}})
+
+		// Assert
+		assert.ErrorContains(t, errSelector, `parsing selector of namespace tier "broken"`)
+		assert.ErrorContains(t, errInterval, `interval of namespace tier "zero" must be positive`)
+		assert.Len(t, s.tiers, 2)
+	})
+
+	t.Run("the namespaces stay due until validated", func(t *testing.T) {
+		// Given
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := newTestScheduler(t, &now)
+		_, _ = s.due([]namespace{prod, dev})
+		s.schedule(prod)
+
+		// When
+		now = now.Add(time.Minute)
+		due, skipped := s.due([]namespace{prod, dev})
+
+		// Assert
+		assert.Equal(t, []namespace{dev}, due, "the validation of dev failed")
+		assert.Equal(t, []namespace{prod}, skipped)
+		assert.Equal(t, time.Minute, s.wait())
+	})
+}
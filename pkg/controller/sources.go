@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// sourceCacheTTL is the time the repository of an application is cached for
+const sourceCacheTTL = 10 * time.Minute
+
+// The GitOps resources read to locate the repository of the applications
+var (
+	argoCDApplicationGVK = schema.GroupVersionKind{
+		Group: "argoproj.io", Version: "v1alpha1", Kind: "Application",
+	}
+	fluxKustomizationGVK = schema.GroupVersionKind{
+		Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization",
+	}
+	fluxHelmReleaseGVK = schema.GroupVersionKind{
+		Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease",
+	}
+	// fluxSourceVersions are the versions of the Flux sources, by kind
+	fluxSourceVersions = map[string]string{
+		"GitRepository":  "v1",
+		"HelmRepository": "v1",
+		"Bucket":         "v1",
+		"OCIRepository":  "v1beta2",
+	}
+)
+
+type cachedSource struct {
+	repoURL, path string
+	fetched       time.Time
+	// used tells whether the application has been used since the last pruning
+	used bool
+}
+
+// sourceResolver resolves the GitOps applications of the objects from their tracking
+// metadata, and locates their repository by reading the Argo CD Applications and the
+// Flux Kustomizations and HelmReleases, cached by application. The cache is guarded
+// by mux, which is not held while reading from the API.
+type sourceResolver struct {
+	mux    sync.Mutex
+	client client.Client
+	logger logr.Logger
+	// argoCDNamespace is the namespace Argo CD is installed in
+	argoCDNamespace string
+	cache           map[validations.Source]*cachedSource
+	now             func() time.Time
+}
+
+func newSourceResolver(c client.Client, logger logr.Logger) *sourceResolver {
+	return &sourceResolver{
+		client:          c,
+		logger:          logger,
+		argoCDNamespace: validations.DefaultArgoCDNamespace,
+		cache:           map[validations.Source]*cachedSource{},
+		now:             time.Now,
+	}
+}
+
+// Source returns the application the given object is deployed from, nil if none.
+// Its repository is left empty when it cannot be read.
+func (r *sourceResolver) Source(ctx context.Context, obj client.Object) *validations.Source {
+	source := validations.TrackedSource(obj, r.argoCDNamespace)
+	if source == nil || source.Tool == validations.SourceToolHelm {
+		// the Helm releases do not tell where their chart comes from
+		return source
+	}
+
+	cached, ok := r.cached(*source)
+	if !ok {
+		cached = &cachedSource{fetched: r.now(), used: true}
+		cached.repoURL, cached.path = r.locate(ctx, *source)
+		if ctx.Err() != nil {
+			// the application is read again by the next reconciliation
+			return source
+		}
+		r.mux.Lock()
+		r.cache[*source] = cached
+		r.mux.Unlock()
+	}
+
+	source.RepoURL, source.Path = cached.repoURL, cached.path
+	return source
+}
+
+// cached returns the given application from the cache, if it has not expired
+func (r *sourceResolver) cached(source validations.Source) (*cachedSource, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	cached, ok := r.cache[source]
+	if !ok || r.now().Sub(cached.fetched) >= sourceCacheTTL {
+		return nil, false
+	}
+	cached.used = true
+	return cached, true
+}
+
+// locate returns the repository and the path of the manifests of the given application
+func (r *sourceResolver) locate(ctx context.Context, source validations.Source) (string, string) {
+	switch source.Kind {
+	case argoCDApplicationGVK.Kind:
+		app, ok := r.get(ctx, argoCDApplicationGVK, source.Namespace, source.Name)
+		if !ok {
+			return "", ""
+		}
+		// the first source of the multiple sources applications
+		spec, found, _ := unstructured.NestedMap(app.Object, "spec", "source")
+		if !found {
+			sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+			if len(sources) > 0 {
+				spec, _ = sources[0].(map[string]interface{})
+			}
+		}
+		repoURL, _, _ := unstructured.NestedString(spec, "repoURL")
+		path, _, _ := unstructured.NestedString(spec, "path")
+		if path == "" {
+			path, _, _ = unstructured.NestedString(spec, "chart")
+		}
+		return repoURL, path
+	case fluxKustomizationGVK.Kind:
+		ks, ok := r.get(ctx, fluxKustomizationGVK, source.Namespace, source.Name)
+		if !ok {
+			return "", ""
+		}
+		path, _, _ := unstructured.NestedString(ks.Object, "spec", "path")
+		return r.fluxSourceURL(ctx, ks, "spec", "sourceRef"), path
+	case fluxHelmReleaseGVK.Kind:
+		hr, ok := r.get(ctx, fluxHelmReleaseGVK, source.Namespace, source.Name)
+		if !ok {
+			return "", ""
+		}
+		chart, _, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "chart")
+		return r.fluxSourceURL(ctx, hr, "spec", "chart", "spec", "sourceRef"), chart
+	default:
+		return "", ""
+	}
+}
+
+// fluxSourceURL returns the URL of the Flux source referenced by the given field of the object
+func (r *sourceResolver) fluxSourceURL(ctx context.Context, obj *unstructured.Unstructured,
+	fields ...string) string {
+	ref, _, _ := unstructured.NestedStringMap(obj.Object, fields...)
+	version, ok := fluxSourceVersions[ref["kind"]]
+	if !ok {
+		return ""
+	}
+	namespace := ref["namespace"]
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+
+	gvk := schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: version, Kind: ref["kind"]}
+	src, ok := r.get(ctx, gvk, namespace, ref["name"])
+	if !ok {
+		return ""
+	}
+	url, _, _ := unstructured.NestedString(src.Object, "spec", "url")
+	return url
+}
+
+// get reads the given GitOps resource. The resources not found, not allowed or whose
+// CRD is not installed, as the errors of a cancelled reconciliation, are silently ignored.
+func (r *sourceResolver) get(ctx context.Context, gvk schema.GroupVersionKind,
+	namespace, name string) (*unstructured.Unstructured, bool) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj)
+	if err != nil {
+		ignored := ctx.Err() != nil || apierrors.IsNotFound(err) || apierrors.IsForbidden(err) ||
+			meta.IsNoMatchError(err)
+		if !ignored {
+			r.logger.Error(err, "reading GitOps source",
+				"kind", gvk.Kind, "namespace", namespace, "name", name)
+		}
+		return nil, false
+	}
+	return obj, true
+}
+
+// prune forgets the applications which have not been used since the previous pruning
+func (r *sourceResolver) prune() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for key, cached := range r.cache {
+		if !cached.used {
+			delete(r.cache, key)
+			continue
+		}
+		cached.used = false
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+func newUnstructured(gvk schema.GroupVersionKind, namespace, name string,
+	spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestSourceResolver(t *testing.T) {
+	// Given
+	application := newUnstructured(argoCDApplicationGVK, "argocd", "app", map[string]interface{}{
+		"source": map[string]interface{}{
+			"repoURL": "https://github.com/example/apps.git",
+			"path":    "apps/app/overlays/prod",
+		},
+	})
+	kustomization := newUnstructured(fluxKustomizationGVK, "flux-system", "apps", map[string]interface{}{
+		"path":      "./apps/prod",
+		"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "apps"},
+	})
+	gitRepository := newUnstructured(
+		schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"},
+		"flux-system", "apps", map[string]interface{}{"url": "ssh://git@github.com/example/flux-apps"},
+	)
+
+	gets := 0
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(application, kustomization, gitRepository).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey,
+				obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	r := newSourceResolver(c, logr.Discard())
+	ctx := context.Background()
+
+	deployment := func(labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Labels: labels}}
+	}
+
+	// When
+	argoCD := r.Source(ctx, deployment(map[string]string{"argocd.argoproj.io/instance": "app"}))
+	flux := r.Source(ctx, deployment(map[string]string{
+		"kustomize.toolkit.fluxcd.io/name":      "apps",
+		"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+	}))
+
+	// Assert
+	assert.Equal(t, &validations.Source{
+		Tool: validations.SourceToolArgoCD, Kind: "Application", Namespace: "argocd", Name: "app",
+		RepoURL: "https://github.com/example/apps.git", Path: "apps/app/overlays/prod",
+	}, argoCD)
+	assert.Equal(t, &validations.Source{
+		Tool: validations.SourceToolFlux, Kind: "Kustomization", Namespace: "flux-system", Name: "apps",
+		RepoURL: "ssh://git@github.com/example/flux-apps", Path: "./apps/prod",
+	}, flux)
+	assert.Equal(t, 3, gets)
+
+	assert.Equal(t, argoCD, r.Source(ctx, deployment(map[string]string{"argocd.argoproj.io/instance": "app"})))
+	assert.Equal(t, 3, gets, "the applications are cached")
+
+	assert.Equal(t, &validations.Source{
+		Tool: validations.SourceToolArgoCD, Kind: "Application", Namespace: "argocd", Name: "deleted",
+	}, r.Source(ctx, deployment(map[string]string{"argocd.argoproj.io/instance": "deleted"})),
+		"the repository of the applications not found is unknown")
+	assert.Nil(t, r.Source(ctx, deployment(nil)))
+
+	// When
+	r.argoCDNamespace = "openshift-gitops"
+
+	// Assert
+	assert.Equal(t, &validations.Source{
+		Tool: validations.SourceToolArgoCD, Kind: "Application", Namespace: "openshift-gitops", Name: "app",
+	}, r.Source(ctx, deployment(map[string]string{"argocd.argoproj.io/instance": "app"})),
+		"the applications are looked up in the namespace Argo CD is installed in")
+	assert.Equal(t, 5, gets)
+}
@@ -0,0 +1,332 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// stagedConfigReport summarizes, per check, how the results of the active
+// configuration would change if the staged configuration was promoted
+type stagedConfigReport struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Checks      []stagedCheckDiff `json:"checks"`
+}
+
+type stagedCheckDiff struct {
+	Check            string                `json:"check"`
+	NewFailures      []validations.Failure `json:"newFailures,omitempty"`
+	ResolvedFailures []validations.Failure `json:"resolvedFailures,omitempty"`
+}
+
+type failureKey struct {
+	check, kind, namespace, name, uid string
+}
+
+func newFailureKey(f validations.Failure) failureKey {
+	return failureKey{
+		check:     f.Check,
+		kind:      f.Kind,
+		namespace: f.Namespace,
+		name:      f.Name,
+		uid:       f.UID,
+	}
+}
+
+// newStagedConfigReport compares the failures reported with the active configuration
+// against the ones reported with the staged configuration. Failures present only for the
+// staged configuration are new, failures present only for the active one are resolved.
+func newStagedConfigReport(active, staged []validations.Failure) *stagedConfigReport {
+	activeSet := failureSet(active)
+	stagedSet := failureSet(staged)
+
+	diffs := map[string]*stagedCheckDiff{}
+	getDiff := func(check string) *stagedCheckDiff {
+		if _, ok := diffs[check]; !ok {
+			diffs[check] = &stagedCheckDiff{Check: check}
+		}
+		return diffs[check]
+	}
+
+	for k, f := range stagedSet {
+		if _, ok := activeSet[k]; !ok {
+			d := getDiff(f.Check)
+			d.NewFailures = append(d.NewFailures, f)
+		}
+	}
+	for k, f := range activeSet {
+		if _, ok := stagedSet[k]; !ok {
+			d := getDiff(f.Check)
+			d.ResolvedFailures = append(d.ResolvedFailures, f)
+		}
+	}
+
+	report := &stagedConfigReport{
+		GeneratedAt: time.Now().UTC(),
+		Checks:      make([]stagedCheckDiff, 0, len(diffs)),
+	}
+	for _, d := range diffs {
+		sortFailures(d.NewFailures)
+		sortFailures(d.ResolvedFailures)
+		report.Checks = append(report.Checks, *d)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Check < report.Checks[j].Check
+	})
+
+	return report
+}
+
+// failureSet deduplicates the failures, as the same object can belong to several groups
+func failureSet(failures []validations.Failure) map[failureKey]validations.Failure {
+	set := make(map[failureKey]validations.Failure, len(failures))
+	for _, f := range failures {
+		set[newFailureKey(f)] = f
+	}
+	return set
+}
+
+func sortFailures(failures []validations.Failure) {
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Namespace != failures[j].Namespace {
+			return failures[i].Namespace < failures[j].Namespace
+		}
+		if failures[i].Kind != failures[j].Kind {
+			return failures[i].Kind < failures[j].Kind
+		}
+		return failures[i].Name < failures[j].Name
+	})
+}
+
+// stagedConfigPreviewInterval is the minimum interval between the starts of two previews,
+// as each of them lists the objects of all the watched namespaces from the API server
+const stagedConfigPreviewInterval = time.Minute
+
+// stagedConfigPreview holds the latest staged configuration report
+// and serves it as JSON. A single preview runs at a time, the one of
+// the latest staged configuration.
+type stagedConfigPreview struct {
+	mux    sync.RWMutex
+	report *stagedConfigReport
+	// cancel cancels the preview running, if any
+	cancel context.CancelFunc
+	// interval is the minimum interval between the starts of two previews,
+	// started the time the last one started
+	interval time.Duration
+	started  time.Time
+}
+
+func newStagedConfigPreview(interval time.Duration) *stagedConfigPreview {
+	return &stagedConfigPreview{interval: interval}
+}
+
+// restart cancels the preview running, if any, and returns the context of the next one
+func (p *stagedConfigPreview) restart(ctx context.Context) context.Context {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+	return ctx
+}
+
+// wait waits until the interval since the start of the previous preview has elapsed, so
+// the staged configurations updated in the meantime are previewed once, the latest one.
+// It returns the error of the given context if it is done before.
+func (p *stagedConfigPreview) wait(ctx context.Context) error {
+	p.mux.RLock()
+	delay := time.Until(p.started.Add(p.interval))
+	p.mux.RUnlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.started = time.Now()
+	return nil
+}
+
+// clear cancels the preview running, if any, and removes the report
+func (p *stagedConfigPreview) clear() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.report = nil
+}
+
+// set publishes the report of the preview of the given context, unless
+// it has been cancelled by a more recent preview
+func (p *stagedConfigPreview) set(ctx context.Context, report *stagedConfigReport) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.report = report
+	return nil
+}
+
+func (p *stagedConfigPreview) get() *stagedConfigReport {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	return p.report
+}
+
+// ServeHTTP writes the latest staged configuration report, if any
+func (p *stagedConfigPreview) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	report := p.get()
+	if report == nil {
+		http.Error(w, "no staged configuration found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// StagedConfigReportHandler returns an http.Handler serving the diff report
+// between the active and the staged configuration
+func (gr *GenericReconciler) StagedConfigReportHandler() http.Handler {
+	return gr.stagedConfig
+}
+
+// startStagedConfigPreview previews the given staged configuration in the background,
+// cancelling the preview of the previous one, if still running
+func (gr *GenericReconciler) startStagedConfigPreview(ctx context.Context, staged configmap.Snapshot) {
+	previewCtx := gr.stagedConfig.restart(ctx)
+	go func() {
+		err := gr.previewStagedConfig(previewCtx, staged)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			gr.logger.Error(err, "error previewing staged configuration")
+		}
+	}()
+}
+
+// previewStagedConfig evaluates the staged configuration against the objects of the
+// watched namespaces, without touching the metrics or the validation cache, and
+// publishes the differences with the active configuration. It waits for the API
+// resources to be discovered by the first reconciliation, and previews at most once
+// every stagedConfigPreviewInterval. Both configurations are evaluated by dedicated engines built from their Snapshot,
+// as the engine of the reconciliation loop is updated along the configuration.
+func (gr *GenericReconciler) previewStagedConfig(ctx context.Context, stagedConfig configmap.Snapshot) error {
+	select {
+	case <-gr.apiResourcesReady:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := gr.stagedConfig.wait(ctx); err != nil {
+		return err
+	}
+
+	activeEngine, err := gr.newPreviewEngine(gr.cmWatcher.ActiveConfig())
+	if err != nil {
+		return fmt.Errorf("initializing active validation engine: %w", err)
+	}
+	candidate, err := gr.newPreviewEngine(stagedConfig)
+	if err != nil {
+		return fmt.Errorf("initializing staged validation engine: %w", err)
+	}
+
+	// a dedicated cache avoids racing with the namespaces used by the reconciliation loop
+	nsCache := &watchNamespacesCache{names: gr.watchNamespaces.names, filter: gr.watchNamespaces.getFilter()}
+	namespaces, err := nsCache.getWatchNamespaces(ctx, gr.client)
+	if err != nil {
+		return fmt.Errorf("getting watched namespaces: %w", err)
+	}
+
+	var active, staged []validations.Failure
+	gvks := gr.getNamespacedResourcesGVK(gr.apiResources)
+	for _, ns := range *namespaces {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// the Helm releases found are left to the validation of the namespace
+		relatedObjects, _, err := gr.groupAppObjects(ctx, ns.name, gvks)
+		if err != nil {
+			return err
+		}
+
+		for _, objs := range relatedObjects {
+			cliObjects, err := gr.unstructuredListToTyped(objs)
+			if err != nil {
+				return err
+			}
+
+			activeFailures, err := activeEngine.EvaluateObjects(ctx, cliObjects, ns.metadata())
+			if err != nil {
+				return fmt.Errorf("evaluating active configuration: %w", err)
+			}
+			active = append(active, activeFailures...)
+
+			stagedFailures, err := candidate.EvaluateObjects(ctx, cliObjects, ns.metadata())
+			if err != nil {
+				return fmt.Errorf("evaluating staged configuration: %w", err)
+			}
+			staged = append(staged, stagedFailures...)
+		}
+	}
+
+	report := newStagedConfigReport(active, staged)
+	if err := gr.stagedConfig.set(ctx, report); err != nil {
+		return err
+	}
+
+	for _, d := range report.Checks {
+		gr.logger.Info("Staged configuration preview",
+			"check", d.Check,
+			"newFailures", len(d.NewFailures),
+			"resolvedFailures", len(d.ResolvedFailures),
+		)
+	}
+
+	return nil
+}
+
+// newPreviewEngine returns a validation engine, without metrics, applying the given
+// configuration. It shares the resolvers of the owners and of the sources of the
+// objects with the engine of the reconciliation loop, which are set once at startup.
+func (gr *GenericReconciler) newPreviewEngine(snapshot configmap.Snapshot) (validations.Interface, error) {
+	engine, err := validations.NewValidationEngineFromConfig(snapshot.Config(), nil)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetImages(snapshot.Images())
+	if err := engine.InitRegistry(); err != nil {
+		return nil, err
+	}
+	engine.SetReplicas(snapshot.Replicas())
+	engine.SetOwnerResolver(gr.validationEngine.GetOwnerResolver())
+	engine.SetSourceResolver(gr.validationEngine.GetSourceResolver())
+	if err := engine.SetOwnership(snapshot.Ownership()); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStagedConfigReport(t *testing.T) {
+	newFailure := func(check, name string) validations.Failure {
+		return validations.Failure{Check: check, Kind: "Deployment", Namespace: "ns", Name: name, UID: name}
+	}
+	depA := newFailure("unset-cpu-requirements", "a")
+	depB := newFailure("unset-cpu-requirements", "b")
+	depARoot := newFailure("run-as-non-root", "a")
+
+	tests := []struct {
+		name     string
+		active   []validations.Failure
+		staged   []validations.Failure
+		expected []stagedCheckDiff
+	}{
+		{
+			name:     "same failures produce an empty report",
+			active:   []validations.Failure{depA, depB},
+			staged:   []validations.Failure{depB, depA},
+			expected: []stagedCheckDiff{},
+		},
+		{
+			name:   "new and resolved failures are reported per check",
+			active: []validations.Failure{depA, depARoot},
+			staged: []validations.Failure{depA, depB},
+			expected: []stagedCheckDiff{
+				{Check: "run-as-non-root", ResolvedFailures: []validations.Failure{depARoot}},
+				{Check: "unset-cpu-requirements", NewFailures: []validations.Failure{depB}},
+			},
+		},
+		{
+			name:   "duplicated failures from several groups are reported once",
+			active: []validations.Failure{},
+			staged: []validations.Failure{depA, depA},
+			expected: []stagedCheckDiff{
+				{Check: "unset-cpu-requirements", NewFailures: []validations.Failure{depA}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			report := newStagedConfigReport(tt.active, tt.staged)
+
+			// Assert
+			assert.Equal(t, tt.expected, report.Checks)
+		})
+	}
+}
+
+func TestStagedConfigPreviewServeHTTP(t *testing.T) {
+	t.Run("no report returns not found", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		preview := stagedConfigPreview{}
+
+		preview.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("existing report is encoded as JSON", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		preview := stagedConfigPreview{}
+		err := preview.set(context.Background(),
+			newStagedConfigReport(nil, []validations.Failure{{Check: "host-pid", Name: "a"}}))
+		assert.NoError(t, err)
+
+		preview.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), `"check":"host-pid"`)
+	})
+}
+
+func TestStagedConfigPreviewRestart(t *testing.T) {
+	// Given
+	preview := stagedConfigPreview{}
+	first := preview.restart(context.Background())
+	second := preview.restart(context.Background())
+
+	// When
+	errFirst := preview.set(first, newStagedConfigReport(nil, []validations.Failure{{Check: "first"}}))
+	errSecond := preview.set(second, newStagedConfigReport(nil, []validations.Failure{{Check: "second"}}))
+
+	// Assert
+	assert.ErrorIs(t, errFirst, context.Canceled, "the first preview has been superseded")
+	assert.NoError(t, errSecond)
+	assert.Equal(t, "second", preview.get().Checks[0].Check)
+
+	preview.clear()
+	assert.Nil(t, preview.get())
+	assert.ErrorIs(t, second.Err(), context.Canceled)
+}
+
+func TestStagedConfigPreviewWait(t *testing.T) {
+	// Given
+	interval := 100 * time.Millisecond
+	preview := newStagedConfigPreview(interval)
+	assert.NoError(t, preview.wait(context.Background()), "the first preview starts right away")
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// When
+	start := time.Now()
+	errCancelled := preview.wait(cancelled)
+	err := preview.wait(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, errCancelled, context.Canceled)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), interval/2, "the next preview waits for the interval")
+}
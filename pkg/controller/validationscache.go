@@ -60,6 +60,24 @@ func newValidationCache() *validationCache {
 	return &validationCache{}
 }
 
+// storeNamespacesKeys stores in the instance the entries of the given cache
+// belonging to the given namespaces, in a single pass over the cache.
+func (vc *validationCache) storeNamespacesKeys(src *validationCache, namespaces []namespace) {
+	if len(namespaces) == 0 {
+		return
+	}
+	names := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		names[ns.name] = struct{}{}
+	}
+
+	for key, val := range *src {
+		if _, ok := names[key.namespace]; ok {
+			(*vc)[key] = val
+		}
+	}
+}
+
 // has returns 'true' if the given key exist in the instance; 'false' otherwise.
 func (vc *validationCache) has(key validationKey) bool {
 	_, exists := (*vc)[key]
@@ -69,13 +87,24 @@ func (vc *validationCache) has(key validationKey) bool {
 // store caches a 'ValidationOutcome' for the given 'Object'.
 // constraint: cached outcomes will be updated in-place for a given object and
 // consecutive updates will not preserve previous state.
-func (vc *validationCache) store(obj client.Object, nsID string, outcome validations.ValidationOutcome) {
+// The previously cached 'ValidationOutcome' is returned, if any, so that
+// callers can detect the objects whose outcome changed.
+func (vc *validationCache) store(
+	obj client.Object,
+	nsID string,
+	outcome validations.ValidationOutcome,
+) (validations.ValidationOutcome, bool) {
 	key := newValidationKey(obj, nsID)
+	previous, exists := (*vc)[key]
 	(*vc)[key] = newValidationResource(
 		newResourceversionVal(obj.GetResourceVersion()),
 		string(obj.GetUID()),
 		outcome,
 	)
+	if !exists {
+		return "", false
+	}
+	return previous.outcome, true
 }
 
 // drain frees the cache of any used resources
@@ -84,6 +113,16 @@ func (vc *validationCache) drain() {
 	*vc = validationCache{}
 }
 
+// invalidate marks every cached 'ValidationOutcome' as outdated so
+// that the objects are validated again on the next reconciliation.
+// Unlike drain, the keys are preserved so that objects deleted in
+// the meantime are still detected and have their metrics removed.
+func (vc *validationCache) invalidate() {
+	for _, v := range *vc {
+		v.version = ""
+	}
+}
+
 // remove uncaches the 'ValidationOutcome' for the
 // given object if it exists and performs a noop
 // if it does not.
@@ -109,8 +148,9 @@ func (vc *validationCache) retrieve(obj client.Object, nsID string) (*validation
 // objectAlreadyValidated returns 'true' if the given 'Object'
 // has a cached 'ValidationOutcome' with the same 'ResourceVersion'
 // (Kubernetes representation of iteration count for a persisted resource).
-// If the 'ResourceVersion' of an existing 'Object' is stale the cached
-// 'ValidationOutcome' is removed and 'false' is returned. In all other
+// If the 'ResourceVersion' of an existing 'Object' is stale 'false' is
+// returned, the cached 'ValidationOutcome' being kept until the object is
+// validated again so that 'store' can compare both outcomes. In all other
 // cases 'false' is returned.
 func (vc *validationCache) objectAlreadyValidated(obj client.Object, nsID string) bool {
 	validationOutcome, ok := vc.retrieve(obj, nsID)
@@ -119,9 +159,5 @@ func (vc *validationCache) objectAlreadyValidated(obj client.Object, nsID string
 	}
 	storedResourceVersion := validationOutcome.version
 	currentResourceVersion := obj.GetResourceVersion()
-	if string(storedResourceVersion) != currentResourceVersion {
-		vc.remove(obj, nsID)
-		return false
-	}
-	return true
+	return string(storedResourceVersion) == currentResourceVersion
 }
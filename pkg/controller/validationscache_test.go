@@ -10,6 +10,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // TestValidationCache runs four tests on validationscache file's functions
@@ -59,7 +60,7 @@ func TestValidationsCache(t *testing.T) {
 			UID:             "mock_uid",
 		}}
 		mock.store(&mockClientObject, "", "mock_outcome")
-		toBeRemovedKey := newValidationKey(&mockClientObject, "")
+		staleKey := newValidationKey(&mockClientObject, "")
 
 		// When
 		mockClientObject.ResourceVersion = "mock_new_version"
@@ -67,7 +68,12 @@ func TestValidationsCache(t *testing.T) {
 
 		// Assert
 		assert.False(t, test)
-		assert.False(t, mock.has(toBeRemovedKey))
+		// the stale outcome is kept for the next store to compare with
+		assert.True(t, mock.has(staleKey))
+		previous, ok := mock.store(&mockClientObject, "", "mock_new_outcome")
+		assert.True(t, ok)
+		assert.Equal(t, validations.ValidationOutcome("mock_outcome"), previous)
+		assert.True(t, mock.objectAlreadyValidated(&mockClientObject, ""))
 	})
 
 	t.Run("objectAlreadyValidated : OK", func(t *testing.T) {
@@ -114,6 +120,45 @@ func TestValidationsCache(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, validations.ObjectValid, resource2.outcome)
 	})
+
+	t.Run("invalidate forces revalidation but keeps the keys", func(t *testing.T) {
+		// Given
+		mock := newValidationCache()
+		mockClientObject := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			ResourceVersion: "mock_version",
+			UID:             "mock_uid",
+		}}
+		mock.store(&mockClientObject, "", "mock_outcome")
+		key := newValidationKey(&mockClientObject, "")
+
+		// When
+		mock.invalidate()
+
+		// Assert
+		assert.True(t, mock.has(key))
+		assert.False(t, mock.objectAlreadyValidated(&mockClientObject, ""))
+	})
+
+	t.Run("storeNamespacesKeys copies the entries of the given namespaces", func(t *testing.T) {
+		// Given
+		src := newValidationCache()
+		objects := map[string]*appsv1.Deployment{}
+		for _, ns := range []string{"a", "b", "c"} {
+			objects[ns] = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+				Name: "app", Namespace: ns, UID: types.UID("uid-" + ns),
+			}}
+			src.store(objects[ns], ns, validations.ObjectValid)
+		}
+		dst := newValidationCache()
+
+		// When
+		dst.storeNamespacesKeys(src, []namespace{{name: "a"}, {name: "c"}})
+
+		// Assert
+		assert.Len(t, *dst, 2)
+		assert.True(t, dst.has(newValidationKey(objects["a"], "a")))
+		assert.True(t, dst.has(newValidationKey(objects["c"], "c")))
+	})
 }
 
 func printMemoryInfo(s string) {
@@ -5,46 +5,146 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sync"
 
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
 )
 
 // TODO : Evaluate changing uid type: string -> types.UID
 // This data is populated with types.UID from corev1/ObjectMeta
 // No access to this property out of the method getNamespaceUID
 type namespace struct {
-	uid, name string
+	uid, name   string
+	labels      map[string]string
+	annotations map[string]string
 }
 
-type watchNamespacesCache struct {
-	namespaces    *[]namespace
+// metadata returns the metadata of the namespace used by the validation engine
+func (ns namespace) metadata() validations.Namespace {
+	return validations.Namespace{UID: ns.uid, Labels: ns.labels, Annotations: ns.annotations}
+}
+
+// namespaceFilter selects the namespaces validated, see configmap.NamespacesConfig
+type namespaceFilter struct {
 	ignorePattern *regexp.Regexp
+	include       labels.Selector
+	exclude       labels.Selector
+}
+
+// newNamespaceFilter returns the filter of the given configuration. The ignore pattern
+// defaults to the EnvNamespaceIgnorePattern Env variable when not configured.
+func newNamespaceFilter(cfg configmap.NamespacesConfig) (namespaceFilter, error) {
+	if cfg.IgnorePattern == "" {
+		cfg.IgnorePattern = os.Getenv(EnvNamespaceIgnorePattern)
+	}
+
+	var filter namespaceFilter
+	var err error
+	if cfg.IgnorePattern != "" {
+		if filter.ignorePattern, err = regexp.Compile(cfg.IgnorePattern); err != nil {
+			return namespaceFilter{}, fmt.Errorf("parsing namespaces ignore pattern: %w", err)
+		}
+	}
+	if cfg.IncludeSelector != "" {
+		if filter.include, err = labels.Parse(cfg.IncludeSelector); err != nil {
+			return namespaceFilter{}, fmt.Errorf("parsing namespaces include selector: %w", err)
+		}
+	}
+	if cfg.ExcludeSelector != "" {
+		if filter.exclude, err = labels.Parse(cfg.ExcludeSelector); err != nil {
+			return namespaceFilter{}, fmt.Errorf("parsing namespaces exclude selector: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// matches returns true if the namespace is validated. The NamespaceValidateAnnotation
+// annotation takes precedence over the ignore pattern and the selectors.
+func (f namespaceFilter) matches(ns corev1.Namespace) bool {
+	switch ns.GetAnnotations()[NamespaceValidateAnnotation] {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if f.ignorePattern != nil && f.ignorePattern.MatchString(ns.GetName()) {
+		return false
+	}
+	nsLabels := labels.Set(ns.GetLabels())
+	if f.include != nil && !f.include.Matches(nsLabels) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.Matches(nsLabels)
+}
+
+type watchNamespacesCache struct {
+	namespaces *[]namespace
+	// names restricts the namespaces to the ones set in WATCH_NAMESPACE, all the
+	// namespaces of the cluster being considered when it is empty
+	names []string
+	// filterMux guards the filter, updated along the configuration
+	filterMux sync.RWMutex
+	filter    namespaceFilter
+	logger    logr.Logger
+	// forbidden are the watched namespaces which could not be read the last time, logged
+	// once until they can be read again, as they are read every time they are reconciled
+	forbidden map[string]struct{}
 }
 
 // newWatchNamespacesCache returns a new watchNamespacesCache instance
-// if EnvNamespaceIgnorePattern Env variable is set, ignorePattern field
-// is populated with the information in the variable
-// sidenote: regexp will throw a Panic in case data cannot be parsed
-func newWatchNamespacesCache() *watchNamespacesCache {
-	ignorePatternStr := os.Getenv(EnvNamespaceIgnorePattern)
-	var nsIgnoreRegex *regexp.Regexp
-	if ignorePatternStr != "" {
-		nsIgnoreRegex = regexp.MustCompile(ignorePatternStr)
+// filtering the namespaces with the given configuration
+func newWatchNamespacesCache(cfg configmap.NamespacesConfig) (*watchNamespacesCache, error) {
+	filter, err := newNamespaceFilter(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return &watchNamespacesCache{
-		ignorePattern: nsIgnoreRegex,
+	return &watchNamespacesCache{filter: filter, logger: ctrl.Log.WithName("watchNamespaces")}, nil
+}
+
+// setFilter replaces the filter with the one of the given configuration,
+// applied from the next time the cache is populated. The current filter
+// is kept if the configuration is not valid.
+func (nsc *watchNamespacesCache) setFilter(cfg configmap.NamespacesConfig) error {
+	filter, err := newNamespaceFilter(cfg)
+	if err != nil {
+		return err
 	}
+
+	nsc.filterMux.Lock()
+	defer nsc.filterMux.Unlock()
+	nsc.filter = filter
+	return nil
+}
+
+func (nsc *watchNamespacesCache) getFilter() namespaceFilter {
+	nsc.filterMux.RLock()
+	defer nsc.filterMux.RUnlock()
+	return nsc.filter
 }
 
 // getFormattedNamespaces returns a list of namespaces filtering through given list
 // and formatting them as namespace structs
-func getFormattedNamespaces(list corev1.NamespaceList, ignorePattern *regexp.Regexp) (fns []namespace) {
+func getFormattedNamespaces(list corev1.NamespaceList, filter namespaceFilter) (fns []namespace) {
 	for _, ns := range list.Items {
-		name := ns.GetName()
-		if ignorePattern == nil || !ignorePattern.Match([]byte(name)) {
-			fns = append(fns, namespace{uid: string(ns.GetUID()), name: name})
+		if !filter.matches(ns) {
+			continue
 		}
+		fns = append(fns, namespace{
+			uid:         string(ns.GetUID()),
+			name:        ns.GetName(),
+			labels:      ns.GetLabels(),
+			annotations: ns.GetAnnotations(),
+		})
 	}
 	return
 }
@@ -71,17 +171,68 @@ func (nsc *watchNamespacesCache) resetCache() {
 
 // getWatchNamespaces returns the namespaces field with a list of namespaces structs
 // If the field was not set, it will populate it with objects from given client
-// If the ignorePattern field is set, it will filter the matches
+// filtered by the current filter
 func (nsc *watchNamespacesCache) getWatchNamespaces(ctx context.Context, c client.Client) (*[]namespace, error) {
 	if nsc.namespaces == nil {
 		namespaceList := corev1.NamespaceList{}
-		if err := c.List(ctx, &namespaceList); err != nil {
+		if len(nsc.names) > 0 {
+			items, forbidden, err := getNamedNamespaces(ctx, c, nsc.names)
+			if err != nil {
+				return nil, err
+			}
+			nsc.logForbidden(forbidden)
+			namespaceList.Items = items
+		} else if err := c.List(ctx, &namespaceList); err != nil {
 			return nil, fmt.Errorf("listing %s: %w", namespaceList.GroupVersionKind().String(), err)
 		}
 
-		watchNamespaces := getFormattedNamespaces(namespaceList, nsc.ignorePattern)
+		watchNamespaces := getFormattedNamespaces(namespaceList, nsc.getFilter())
 		nsc.setCache(&watchNamespaces)
 	}
 
 	return nsc.namespaces, nil
 }
+
+// logForbidden logs the given namespaces which cannot be read, except the ones
+// which could not be read the previous time either, already logged
+func (nsc *watchNamespacesCache) logForbidden(names []string) {
+	forbidden := make(map[string]struct{}, len(names))
+	var unlogged []string
+	for _, name := range names {
+		forbidden[name] = struct{}{}
+		if _, ok := nsc.forbidden[name]; !ok {
+			unlogged = append(unlogged, name)
+		}
+	}
+	nsc.forbidden = forbidden
+
+	if len(unlogged) > 0 {
+		nsc.logger.Info("namespaces not allowed to be read, validating them "+
+			"without their uid, labels and annotations", "namespaces", unlogged)
+	}
+}
+
+// getNamedNamespaces returns the given namespaces, without listing the ones of the cluster.
+// As namespace-scoped Roles cannot grant access to the namespaces themselves, a namespace
+// which cannot be read is returned with its name only, without uid, labels nor annotations,
+// and its name is returned in forbidden. The namespaces which do not exist are skipped.
+func getNamedNamespaces(ctx context.Context, c client.Client,
+	names []string) (items []corev1.Namespace, forbidden []string, err error) {
+	items = make([]corev1.Namespace, 0, len(names))
+	for _, name := range names {
+		ns := corev1.Namespace{}
+		err = c.Get(ctx, client.ObjectKey{Name: name}, &ns)
+		switch {
+		case err == nil:
+		case apierrors.IsForbidden(err):
+			ns = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+			forbidden = append(forbidden, name)
+		case apierrors.IsNotFound(err):
+			continue
+		default:
+			return nil, nil, fmt.Errorf("getting namespace %s: %w", name, err)
+		}
+		items = append(items, ns)
+	}
+	return items, forbidden, nil
+}
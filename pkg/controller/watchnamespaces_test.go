@@ -1,18 +1,24 @@
 package controller
 
 import (
-	"os"
-	"regexp"
+	"context"
 	"testing"
 
+	"github.com/app-sre/deployment-validation-operator/pkg/configmap"
+	"github.com/go-logr/logr/funcr"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clifake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
-// TestWatchNamespacesCache runs five tests on watchNamespacesCache struct methods:
-// - checks that instantiation takes care of env variable conf
+// TestWatchNamespacesCache runs tests on watchNamespacesCache struct methods:
+// - checks that instantiation takes care of env variable conf and of invalid configurations
 // - checks getNamespaceUID returns valid uid or empty string depending on argument
 // - checks getFormattedNamespaces returns formatted
 // - checks getFormattedNamespaces filters unwanted namespaces
@@ -20,13 +26,54 @@ func TestWatchNamespacesCache(t *testing.T) {
 
 	t.Run("instantiation with ignore pattern Env variable set", func(t *testing.T) {
 		// Given
-		os.Setenv(EnvNamespaceIgnorePattern, "mock")
+		t.Setenv(EnvNamespaceIgnorePattern, "mock")
 
 		// When
-		wnc := newWatchNamespacesCache()
+		wnc, err := newWatchNamespacesCache(configmap.NamespacesConfig{})
 
 		// Assert
-		assert.Equal(t, "mock", wnc.ignorePattern.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "mock", wnc.filter.ignorePattern.String())
+	})
+
+	t.Run("the configured ignore pattern overrides the Env variable", func(t *testing.T) {
+		// Given
+		t.Setenv(EnvNamespaceIgnorePattern, "mock")
+
+		// When
+		wnc, err := newWatchNamespacesCache(configmap.NamespacesConfig{IgnorePattern: "^kube-"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "^kube-", wnc.filter.ignorePattern.String())
+	})
+
+	t.Run("invalid patterns and selectors are errors", func(t *testing.T) {
+		// Given
+		t.Setenv(EnvNamespaceIgnorePattern, "(")
+
+		// When
+		_, errEnv := newWatchNamespacesCache(configmap.NamespacesConfig{})
+		_, errSelector := newWatchNamespacesCache(configmap.NamespacesConfig{
+			IgnorePattern: "mock", IncludeSelector: "env in (",
+		})
+
+		// Assert
+		assert.ErrorContains(t, errEnv, "parsing namespaces ignore pattern")
+		assert.ErrorContains(t, errSelector, "parsing namespaces include selector")
+	})
+
+	t.Run("an invalid filter update keeps the current filter", func(t *testing.T) {
+		// Given
+		wnc, err := newWatchNamespacesCache(configmap.NamespacesConfig{IgnorePattern: "mock"})
+		assert.NoError(t, err)
+
+		// When
+		err = wnc.setFilter(configmap.NamespacesConfig{ExcludeSelector: "!!"})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Equal(t, "mock", wnc.getFilter().ignorePattern.String())
 	})
 
 	t.Run("getNamespaceUID returns an existing uid", func(t *testing.T) {
@@ -75,7 +122,7 @@ func TestWatchNamespacesCache(t *testing.T) {
 		}
 
 		// When
-		ns := getFormattedNamespaces(mockNamespaceList, nil)
+		ns := getFormattedNamespaces(mockNamespaceList, namespaceFilter{})
 
 		// Assert
 		assert.Len(t, ns, 1)
@@ -93,10 +140,106 @@ func TestWatchNamespacesCache(t *testing.T) {
 			},
 		}
 
+		filter, err := newNamespaceFilter(configmap.NamespacesConfig{IgnorePattern: "mock"})
+		assert.NoError(t, err)
+
 		// When
-		ns := getFormattedNamespaces(mockNamespaceList, regexp.MustCompile("mock"))
+		ns := getFormattedNamespaces(mockNamespaceList, filter)
 
 		// Assert
 		assert.Len(t, ns, 0)
 	})
+
+	t.Run("getWatchNamespaces filters by labels and annotations", func(t *testing.T) {
+		// Given
+		newNamespace := func(name string, labels, annotations map[string]string) *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: v1.ObjectMeta{
+				Name: name, Labels: labels, Annotations: annotations,
+			}}
+		}
+		c := clifake.NewClientBuilder().WithObjects(
+			newNamespace("dev", map[string]string{"env": "dev"}, nil),
+			newNamespace("prod", map[string]string{"env": "prod"}, nil),
+			newNamespace("no-env", nil, nil),
+			newNamespace("skipped", map[string]string{"env": "dev", "dvo": "skip"}, nil),
+			newNamespace("opted-out", map[string]string{"env": "dev"},
+				map[string]string{NamespaceValidateAnnotation: "false"}),
+			newNamespace("opted-in", map[string]string{"dvo": "skip"},
+				map[string]string{NamespaceValidateAnnotation: "true"}),
+			newNamespace("ignored", map[string]string{"env": "dev"}, nil),
+		).Build()
+		wnc, err := newWatchNamespacesCache(configmap.NamespacesConfig{
+			IgnorePattern:   "^ignored$",
+			IncludeSelector: "env in (dev, prod)",
+			ExcludeSelector: "dvo=skip",
+		})
+		assert.NoError(t, err)
+
+		// When
+		namespaces, err := wnc.getWatchNamespaces(context.Background(), c)
+
+		// Assert
+		assert.NoError(t, err)
+		var names []string
+		for _, ns := range *namespaces {
+			names = append(names, ns.name)
+		}
+		assert.ElementsMatch(t, []string{"dev", "prod", "opted-in"}, names)
+	})
+
+	t.Run("getWatchNamespaces only reads the watched namespaces", func(t *testing.T) {
+		// Given
+		forbidden := func(name string) error {
+			return apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, name, nil)
+		}
+		forbiddenNames := map[string]bool{"forbidden": true}
+		c := clifake.NewClientBuilder().
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: v1.ObjectMeta{
+					Name: "readable", UID: "uid", Labels: map[string]string{"env": "dev"},
+				}},
+				&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "other"}},
+			).
+			WithInterceptorFuncs(interceptor.Funcs{
+				List: func(context.Context, client.WithWatch, client.ObjectList,
+					...client.ListOption) error {
+					return forbidden("")
+				},
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey,
+					obj client.Object, opts ...client.GetOption) error {
+					if forbiddenNames[key.Name] {
+						return forbidden(key.Name)
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+			}).Build()
+		wnc, err := newWatchNamespacesCache(configmap.NamespacesConfig{})
+		assert.NoError(t, err)
+		wnc.names = []string{"readable", "forbidden", "missing"}
+		var logged []string
+		wnc.logger = funcr.New(func(_, args string) { logged = append(logged, args) }, funcr.Options{})
+
+		// When
+		_, err = wnc.getWatchNamespaces(context.Background(), c)
+		assert.NoError(t, err)
+		wnc.resetCache()
+		namespaces, err := wnc.getWatchNamespaces(context.Background(), c)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []namespace{
+			{uid: "uid", name: "readable", labels: map[string]string{"env": "dev"}},
+			{name: "forbidden"},
+		}, *namespaces)
+		assert.Len(t, logged, 1)
+		assert.Contains(t, logged[0], `"namespaces"=["forbidden"]`)
+
+		// a namespace which can no longer be read is logged too
+		forbiddenNames["readable"] = true
+		wnc.resetCache()
+		_, err = wnc.getWatchNamespaces(context.Background(), c)
+		assert.NoError(t, err)
+		assert.Len(t, logged, 2)
+		assert.Contains(t, logged[1], `"namespaces"=["readable"]`)
+	})
 }
@@ -0,0 +1,164 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// ReleaseSecretType is the type of the Secrets Helm 3 stores the releases in
+	ReleaseSecretType corev1.SecretType = "helm.sh/release.v1"
+	// ReleaseSecretSelector selects the Secrets of the deployed releases
+	ReleaseSecretSelector = "owner=helm,status=deployed"
+
+	// StatusDeployed is the status of the release currently deployed
+	StatusDeployed = "deployed"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// Release is a Helm release, as stored by Helm in its release Secrets
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Revision is the version of the release, increased by every upgrade
+	Revision     int    `json:"revision"`
+	Status       string `json:"status"`
+	Chart        string `json:"chart"`
+	ChartVersion string `json:"chartVersion"`
+	AppVersion   string `json:"appVersion,omitempty"`
+	// Resources are the objects rendered by the chart
+	Resources []Resource `json:"-"`
+}
+
+// Resource identifies an object of a release. Its namespace is empty when
+// it is not set in the manifest, the object being in the release namespace.
+type Resource struct {
+	schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+// storedRelease holds the fields read from the JSON encoding of the releases by Helm
+type storedRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Manifest string `json:"manifest"`
+}
+
+// DecodeRelease decodes the release stored in the given Helm release Secret: the JSON
+// encoding of the release, gzipped and base64 encoded in its release key
+func DecodeRelease(secret *corev1.Secret) (Release, error) {
+	if secret.Type != ReleaseSecretType {
+		return Release{}, fmt.Errorf("secret %s is not a Helm release", secret.GetName())
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(secret.Data["release"]))
+	if err != nil {
+		return Release{}, fmt.Errorf("decoding release %s: %w", secret.GetName(), err)
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return Release{}, fmt.Errorf("decompressing release %s: %w", secret.GetName(), err)
+		}
+		defer r.Close()
+		if data, err = io.ReadAll(r); err != nil {
+			return Release{}, fmt.Errorf("decompressing release %s: %w", secret.GetName(), err)
+		}
+	}
+
+	var stored storedRelease
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Release{}, fmt.Errorf("unmarshalling release %s: %w", secret.GetName(), err)
+	}
+	resources, err := manifestResources(stored.Manifest)
+	if err != nil {
+		return Release{}, fmt.Errorf("reading manifest of release %s: %w", secret.GetName(), err)
+	}
+
+	return Release{
+		Name:         stored.Name,
+		Namespace:    stored.Namespace,
+		Revision:     stored.Version,
+		Status:       stored.Info.Status,
+		Chart:        stored.Chart.Metadata.Name,
+		ChartVersion: stored.Chart.Metadata.Version,
+		AppVersion:   stored.Chart.Metadata.AppVersion,
+		Resources:    resources,
+	}, nil
+}
+
+// manifestResources returns the objects of the given multi-document manifest
+func manifestResources(manifest string) ([]Resource, error) {
+	var resources []Resource
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resources, nil
+			}
+			return nil, err
+		}
+		// empty documents and the documents made of comments only
+		if len(u.Object) == 0 {
+			continue
+		}
+		resources = append(resources, Resource{
+			GroupKind: u.GroupVersionKind().GroupKind(),
+			Namespace: u.GetNamespace(),
+			Name:      u.GetName(),
+		})
+	}
+}
+
+// Contains returns true if the object of the given kind, namespace and name is part of the release
+func (r Release) Contains(gk schema.GroupKind, namespace, name string) bool {
+	for _, res := range r.Resources {
+		if res.GroupKind != gk || res.Name != name {
+			continue
+		}
+		if res.Namespace == namespace || (res.Namespace == "" && r.Namespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the deployed releases of the given ones by name, keeping the
+// highest revision when a release has several of them
+func Latest(releases []Release) map[string]Release {
+	latest := map[string]Release{}
+	for _, r := range releases {
+		if r.Status != StatusDeployed {
+			continue
+		}
+		if previous, ok := latest[r.Name]; !ok || previous.Revision < r.Revision {
+			latest[r.Name] = r
+		}
+	}
+	return latest
+}
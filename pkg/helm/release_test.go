@@ -0,0 +1,81 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/testutils"
+)
+
+const testRelease = `{
+  "name": "payments",
+  "namespace": "shop",
+  "version": 3,
+  "info": {"status": "deployed"},
+  "chart": {"metadata": {"name": "payments", "version": "1.2.0", "appVersion": "4.1"}},
+  "manifest": "` +
+	`---\n# Source: payments/templates/service.yaml\n` +
+	`apiVersion: v1\nkind: Service\nmetadata:\n  name: payments\n` +
+	`---\n# Source: payments/templates/empty.yaml\n` +
+	`---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: payments\n  namespace: shop\n"
+}`
+
+var (
+	service    = schema.GroupKind{Kind: "Service"}
+	deployment = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+)
+
+func newReleaseSecret(t *testing.T, release string, compress bool) *corev1.Secret {
+	secret, err := testutils.NewHelmReleaseSecret("shop", "sh.helm.release.v1.payments.v3", release, compress)
+	assert.NoError(t, err)
+	assert.Equal(t, ReleaseSecretType, secret.Type)
+	return secret
+}
+
+func TestDecodeRelease(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		// Given
+		secret := newReleaseSecret(t, testRelease, compress)
+
+		// When
+		release, err := DecodeRelease(secret)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, Release{
+			Name:         "payments",
+			Namespace:    "shop",
+			Revision:     3,
+			Status:       StatusDeployed,
+			Chart:        "payments",
+			ChartVersion: "1.2.0",
+			AppVersion:   "4.1",
+			Resources: []Resource{
+				{GroupKind: service, Name: "payments"},
+				{GroupKind: deployment, Namespace: "shop", Name: "payments"},
+			},
+		}, release)
+		assert.True(t, release.Contains(service, "shop", "payments"), "in the release namespace")
+		assert.True(t, release.Contains(deployment, "shop", "payments"))
+		assert.False(t, release.Contains(service, "other", "payments"))
+		assert.False(t, release.Contains(deployment, "shop", "other"))
+	}
+
+	secret := newReleaseSecret(t, testRelease, true)
+	secret.Type = corev1.SecretTypeOpaque
+	_, err := DecodeRelease(secret)
+	assert.ErrorContains(t, err, "is not a Helm release")
+}
+
+func TestLatest(t *testing.T) {
+	releases := []Release{
+		{Name: "a", Revision: 1, Status: StatusDeployed},
+		{Name: "a", Revision: 2, Status: StatusDeployed},
+		{Name: "b", Revision: 1, Status: "failed"},
+	}
+
+	assert.Equal(t, map[string]Release{"a": releases[1]}, Latest(releases))
+}
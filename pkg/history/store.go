@@ -0,0 +1,265 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// defaultTrendDays is the period covered by the trends when no start date is requested
+	defaultTrendDays = 90
+	openTimeout      = 10 * time.Second
+)
+
+var (
+	dailyBucket = []byte("daily")
+	// failingSinceBucket holds the time each check was first seen failing,
+	// by object UID and check name
+	failingSinceBucket = []byte("failingSince")
+)
+
+// NamespaceSummary is the validation summary of a namespace on a given day
+type NamespaceSummary struct {
+	// Date is the UTC day of the summary, formatted as YYYY-MM-DD
+	Date      string `json:"date"`
+	Namespace string `json:"namespace"`
+	// Objects is the number of objects validated in the namespace
+	Objects int `json:"objects"`
+	// FailingObjects is the number of objects failing at least one check
+	FailingObjects int `json:"failingObjects"`
+	// Checks holds the number of objects failing each check
+	Checks map[string]int `json:"checks,omitempty"`
+}
+
+// Store keeps a daily snapshot of the namespace summaries in an embedded database,
+// the snapshot of a day being replaced by each summary recorded the same day.
+// The snapshots older than the retention period are pruned.
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+	now       func() time.Time
+}
+
+// Open opens, or creates, the store at the given path
+func Open(path string, retention time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("opening history database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{dailyBucket, failingSinceBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("initializing history database: %w", err)
+	}
+
+	return &Store{db: db, retention: retention, now: time.Now}, nil
+}
+
+// Start closes the store once the context is cancelled
+func (s *Store) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return s.Close()
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record replaces the snapshot of the current day with the given summaries
+// and prunes the snapshots older than the retention period
+func (s *Store) Record(summaries []NamespaceSummary) error {
+	now := s.now().UTC()
+	date := now.Format(dateLayout)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dailyBucket)
+
+		// namespaces deleted since the previous record of the day must not linger
+		if err := deleteRange(b, []byte(date+"/"), []byte(date+"0")); err != nil {
+			return err
+		}
+
+		for _, summary := range summaries {
+			summary.Date = date
+			data, err := json.Marshal(summary)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(summaryKey(date, summary.Namespace), data); err != nil {
+				return err
+			}
+		}
+
+		if s.retention > 0 {
+			cutoff := now.Add(-s.retention).Format(dateLayout)
+			return deleteRange(b, []byte{}, []byte(cutoff))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("recording history: %w", err)
+	}
+	return nil
+}
+
+// RecordFailingSince replaces the recorded times the checks were first seen failing
+// with the given ones, by object UID and check name
+func (s *Store) RecordFailingSince(times map[string]map[string]time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(failingSinceBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucket(failingSinceBucket)
+		if err != nil {
+			return err
+		}
+
+		for uid, checks := range times {
+			for check, since := range checks {
+				value := []byte(since.UTC().Format(time.RFC3339Nano))
+				if err := b.Put(failingSinceKey(uid, check), value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("recording failing since times: %w", err)
+	}
+	return nil
+}
+
+// FailingSince returns the recorded times the checks were first seen failing,
+// by object UID and check name
+func (s *Store) FailingSince() (map[string]map[string]time.Time, error) {
+	times := map[string]map[string]time.Time{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(failingSinceBucket).ForEach(func(k, v []byte) error {
+			uid, check, found := strings.Cut(string(k), "/")
+			if !found {
+				return fmt.Errorf("invalid failing since key %s", k)
+			}
+			since, err := time.Parse(time.RFC3339Nano, string(v))
+			if err != nil {
+				return fmt.Errorf("decoding failing since time %s: %w", k, err)
+			}
+			if _, ok := times[uid]; !ok {
+				times[uid] = map[string]time.Time{}
+			}
+			times[uid][check] = since
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading failing since times: %w", err)
+	}
+	return times, nil
+}
+
+// deleteRange deletes the keys between start, inclusive, and end, exclusive
+func deleteRange(b *bolt.Bucket, start, end []byte) error {
+	c := b.Cursor()
+	// seeking again after each deletion, as deleting moves the cursor
+	for k, _ := c.Seek(start); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Seek(start) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trends returns the daily summaries between the given dates, inclusive,
+// optionally filtered by namespace. They are sorted by date and namespace,
+// as their keys.
+func (s *Store) Trends(from, to, namespace string) ([]NamespaceSummary, error) {
+	summaries := []NamespaceSummary{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(dailyBucket).Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil; k, v = c.Next() {
+			var summary NamespaceSummary
+			if err := json.Unmarshal(v, &summary); err != nil {
+				return fmt.Errorf("decoding summary %s: %w", k, err)
+			}
+			if summary.Date > to {
+				break
+			}
+			if namespace != "" && summary.Namespace != namespace {
+				continue
+			}
+			summaries = append(summaries, summary)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	return summaries, nil
+}
+
+// Handler serves the daily summaries as JSON. The from and to query parameters
+// select the dates, formatted as YYYY-MM-DD, the last 90 days being served by default,
+// and the namespace query parameter selects a single namespace.
+func (s *Store) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		now := s.now().UTC()
+		from := now.AddDate(0, 0, -defaultTrendDays).Format(dateLayout)
+		to := now.Format(dateLayout)
+		for param, value := range map[string]*string{"from": &from, "to": &to} {
+			if query.Get(param) == "" {
+				continue
+			}
+			if _, err := time.Parse(dateLayout, query.Get(param)); err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s date: %v", param, err), http.StatusBadRequest)
+				return
+			}
+			*value = query.Get(param)
+		}
+		// the dates have a fixed width layout, so they sort as strings
+		if from > to {
+			http.Error(w, fmt.Sprintf("the from date %s is after the to date %s", from, to),
+				http.StatusBadRequest)
+			return
+		}
+
+		summaries, err := s.Trends(from, to, query.Get("namespace"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summaries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func summaryKey(date, namespace string) []byte {
+	return []byte(date + "/" + namespace)
+}
+
+func failingSinceKey(uid, check string) []byte {
+	return []byte(uid + "/" + check)
+}
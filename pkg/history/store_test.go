@@ -0,0 +1,139 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(t *testing.T, retention time.Duration, now *time.Time) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"), retention)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestStore(t *testing.T) {
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("the last record of the day replaces the previous ones", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 0, &now)
+		assert.NoError(t, s.Record([]NamespaceSummary{
+			{Namespace: "ns-a", Objects: 2, FailingObjects: 2},
+			{Namespace: "ns-b", Objects: 1},
+		}))
+
+		// When
+		now = day.Add(time.Hour)
+		assert.NoError(t, s.Record([]NamespaceSummary{
+			{Namespace: "ns-a", Objects: 2, FailingObjects: 1, Checks: map[string]int{"check": 1}},
+		}))
+
+		// Assert
+		summaries, err := s.Trends("2024-03-01", "2024-03-01", "")
+		assert.NoError(t, err)
+		assert.Equal(t, []NamespaceSummary{
+			{
+				Date: "2024-03-01", Namespace: "ns-a", Objects: 2, FailingObjects: 1,
+				Checks: map[string]int{"check": 1},
+			},
+		}, summaries)
+	})
+
+	t.Run("the summaries older than the retention are pruned", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 7*24*time.Hour, &now)
+		assert.NoError(t, s.Record([]NamespaceSummary{{Namespace: "ns-a"}}))
+		now = day.AddDate(0, 0, 1)
+		assert.NoError(t, s.Record([]NamespaceSummary{{Namespace: "ns-a"}}))
+
+		// When
+		now = day.AddDate(0, 0, 8)
+		assert.NoError(t, s.Record([]NamespaceSummary{{Namespace: "ns-a"}}))
+
+		// Assert
+		summaries, err := s.Trends("2024-01-01", "2024-12-31", "")
+		assert.NoError(t, err)
+		assert.Len(t, summaries, 2)
+		assert.Equal(t, "2024-03-02", summaries[0].Date)
+		assert.Equal(t, "2024-03-09", summaries[1].Date)
+	})
+
+	t.Run("the trends are served by date and namespace", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 0, &now)
+		assert.NoError(t, s.Record([]NamespaceSummary{{Namespace: "ns-a"}, {Namespace: "ns-b"}}))
+		now = day.AddDate(0, 0, 1)
+		assert.NoError(t, s.Record([]NamespaceSummary{{Namespace: "ns-a"}, {Namespace: "ns-b"}}))
+		rec := httptest.NewRecorder()
+
+		// When
+		req := httptest.NewRequest(http.MethodGet, "/history?from=2024-03-02&namespace=ns-b", nil)
+		s.Handler().ServeHTTP(rec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var summaries []NamespaceSummary
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+		assert.Equal(t, []NamespaceSummary{{Date: "2024-03-02", Namespace: "ns-b"}}, summaries)
+	})
+
+	t.Run("invalid dates are rejected", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 0, &now)
+		rec := httptest.NewRecorder()
+
+		// When
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history?to=yesterday", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("a from date after the to date is rejected", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 0, &now)
+		rec := httptest.NewRecorder()
+
+		// When
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+			"/history?from=2024-03-10&to=2024-03-01", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "the from date 2024-03-10 is after the to date 2024-03-01")
+	})
+
+	t.Run("the failing since times replace the previous ones", func(t *testing.T) {
+		// Given
+		now := day
+		s := newTestStore(t, 0, &now)
+		assert.NoError(t, s.RecordFailingSince(map[string]map[string]time.Time{
+			"uid-a": {"check-a": day},
+			"uid-b": {"check-a": day},
+		}))
+		times := map[string]map[string]time.Time{
+			"uid-a": {"check-a": day, "check-b": day.Add(time.Hour + time.Nanosecond)},
+		}
+
+		// When
+		assert.NoError(t, s.RecordFailingSince(times))
+
+		// Assert
+		recorded, err := s.FailingSince()
+		assert.NoError(t, err)
+		assert.Equal(t, times, recorded)
+	})
+}
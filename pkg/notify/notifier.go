@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	defaultBatchInterval = time.Minute
+	defaultMaxPerMinute  = 6
+	// maxPending bounds the transitions kept while the webhook cannot be reached
+	maxPending     = 1000
+	maxAttempts    = 3
+	requestTimeout = 10 * time.Second
+)
+
+// Options configures the Notifier
+type Options struct {
+	// WebhookURL is the URL the notifications are posted to
+	WebhookURL string
+	// Format is the payload format, one of FormatGeneric, FormatSlack or FormatTeams.
+	// It is ignored when Template is set.
+	Format string
+	// Template is an optional text/template rendering the payload from a Batch
+	Template string
+	// BatchInterval is the period the transitions are accumulated for before being sent
+	BatchInterval time.Duration
+	// MaxPerMinute is the maximum number of notifications sent per minute
+	MaxPerMinute int
+}
+
+// Notifier posts the validation outcome transitions to a webhook. The transitions
+// are batched, and the batches are rate limited and retried on failure.
+type Notifier struct {
+	url           string
+	client        *http.Client
+	tmpl          *template.Template
+	batchInterval time.Duration
+	limiter       *rate.Limiter
+	retryBackoff  time.Duration
+	logger        logr.Logger
+
+	mux     sync.Mutex
+	pending []Transition
+	// index of the pending transition of each object, by UID
+	pendingIdx map[string]int
+}
+
+// NewNotifier returns a Notifier configured by the given options
+func NewNotifier(opts Options) (*Notifier, error) {
+	if opts.WebhookURL == "" {
+		return nil, fmt.Errorf("missing webhook URL")
+	}
+
+	tmpl, err := newTemplate(opts.Format, opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	batchInterval := opts.BatchInterval
+	if batchInterval <= 0 {
+		batchInterval = defaultBatchInterval
+	}
+	maxPerMinute := opts.MaxPerMinute
+	if maxPerMinute <= 0 {
+		maxPerMinute = defaultMaxPerMinute
+	}
+
+	return &Notifier{
+		url:           opts.WebhookURL,
+		client:        &http.Client{Timeout: requestTimeout},
+		tmpl:          tmpl,
+		batchInterval: batchInterval,
+		limiter:       rate.NewLimiter(rate.Every(time.Minute/time.Duration(maxPerMinute)), 1),
+		retryBackoff:  time.Second,
+		logger:        ctrl.Log.WithName("Notifier"),
+		pendingIdx:    map[string]int{},
+	}, nil
+}
+
+// Notify queues the given transition for the next batch. A transition of an object
+// already pending replaces the previous one, and both cancel out if the object is
+// back to its initial outcome.
+func (n *Notifier) Notify(t Transition) {
+	if n == nil {
+		return
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if i, ok := n.pendingIdx[t.UID]; ok {
+		t.From = n.pending[i].From
+		if t.From == t.To {
+			n.removePending(i)
+			return
+		}
+		n.pending[i] = t
+		return
+	}
+
+	if len(n.pending) >= maxPending {
+		n.logger.Info("too many pending notifications, dropping transition",
+			"kind", t.Kind, "namespace", t.Namespace, "name", t.Name)
+		return
+	}
+	n.pendingIdx[t.UID] = len(n.pending)
+	n.pending = append(n.pending, t)
+}
+
+// removePending removes the pending transition at the given index.
+// The caller must hold the lock.
+func (n *Notifier) removePending(i int) {
+	delete(n.pendingIdx, n.pending[i].UID)
+	n.pending = append(n.pending[:i], n.pending[i+1:]...)
+	for j := i; j < len(n.pending); j++ {
+		n.pendingIdx[n.pending[j].UID] = j
+	}
+}
+
+// Start sends the pending transitions every batch interval until the context is cancelled
+func (n *Notifier) Start(ctx context.Context) error {
+	ticker := time.NewTicker(n.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.flush(ctx); err != nil {
+				n.logger.Error(err, "sending notification")
+			}
+		}
+	}
+}
+
+// flush sends the pending transitions as a single batch, unless the rate limit is
+// reached, in which case they are kept for the next attempt. Transitions of a batch
+// that cannot be delivered are dropped.
+func (n *Notifier) flush(ctx context.Context) error {
+	n.mux.Lock()
+	if len(n.pending) == 0 || !n.limiter.Allow() {
+		n.mux.Unlock()
+		return nil
+	}
+	batch := Batch{Transitions: n.pending}
+	n.pending = nil
+	n.pendingIdx = map[string]int{}
+	n.mux.Unlock()
+
+	var payload bytes.Buffer
+	if err := n.tmpl.Execute(&payload, batch); err != nil {
+		return fmt.Errorf("rendering notification payload: %w", err)
+	}
+
+	return n.send(ctx, payload.Bytes())
+}
+
+// send posts the payload to the webhook, retrying with an exponential
+// backoff on network errors, throttling and server errors
+func (n *Notifier) send(ctx context.Context, payload []byte) error {
+	backoff := n.retryBackoff
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var retry bool
+		retry, err = n.post(ctx, payload)
+		if err == nil || !retry {
+			return err
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		n.logger.V(1).Info("retrying notification", "attempt", attempt, "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", maxAttempts, err)
+}
+
+// post sends the payload once. It returns whether a failed request is worth retrying.
+func (n *Notifier) post(ctx context.Context, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookStub records the payloads received, answering with the given status codes in turn
+type webhookStub struct {
+	mux      sync.Mutex
+	statuses []int
+	payloads []string
+}
+
+func (w *webhookStub) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.payloads = append(w.payloads, string(body))
+	status := http.StatusOK
+	if len(w.statuses) > 0 {
+		status, w.statuses = w.statuses[0], w.statuses[1:]
+	}
+	rw.WriteHeader(status)
+}
+
+func newTestNotifier(t *testing.T, url, format string) *Notifier {
+	n, err := NewNotifier(Options{WebhookURL: url, Format: format, MaxPerMinute: 60})
+	assert.NoError(t, err)
+	n.retryBackoff = time.Millisecond
+	return n
+}
+
+func newTestTransition(uid string, from, to validations.ValidationOutcome) Transition {
+	return Transition{Kind: "Deployment", Name: uid, Namespace: "ns", UID: uid, From: from, To: to}
+}
+
+func TestNotifierBatchesTransitions(t *testing.T) {
+	// Given
+	stub := &webhookStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL, FormatGeneric)
+
+	// When
+	n.Notify(newTestTransition("a", validations.ObjectValid, validations.ObjectNeedsImprovement))
+	n.Notify(newTestTransition("b", validations.ObjectNeedsImprovement, validations.ObjectValid))
+	// c is back to its initial outcome, both transitions cancel out
+	n.Notify(newTestTransition("c", validations.ObjectValid, validations.ObjectNeedsImprovement))
+	n.Notify(newTestTransition("c", validations.ObjectNeedsImprovement, validations.ObjectValid))
+	assert.NoError(t, n.flush(context.Background()))
+	// nothing pending, nothing sent
+	assert.NoError(t, n.flush(context.Background()))
+
+	// Assert
+	assert.Len(t, stub.payloads, 1)
+	var batch Batch
+	assert.NoError(t, json.Unmarshal([]byte(stub.payloads[0]), &batch))
+	assert.Len(t, batch.Transitions, 2)
+	assert.Equal(t, "a", batch.NewlyFailing()[0].UID)
+	assert.Equal(t, "b", batch.NewlyFixed()[0].UID)
+}
+
+func TestNotifierRetries(t *testing.T) {
+	t.Run("server errors are retried", func(t *testing.T) {
+		// Given
+		stub := &webhookStub{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+		srv := httptest.NewServer(stub)
+		defer srv.Close()
+		n := newTestNotifier(t, srv.URL, FormatGeneric)
+
+		// When
+		n.Notify(newTestTransition("a", validations.ObjectValid, validations.ObjectNeedsImprovement))
+		err := n.flush(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, stub.payloads, 3)
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		// Given
+		stub := &webhookStub{statuses: []int{http.StatusBadRequest}}
+		srv := httptest.NewServer(stub)
+		defer srv.Close()
+		n := newTestNotifier(t, srv.URL, FormatGeneric)
+
+		// When
+		n.Notify(newTestTransition("a", validations.ObjectValid, validations.ObjectNeedsImprovement))
+		err := n.flush(context.Background())
+
+		// Assert
+		assert.Error(t, err)
+		assert.Len(t, stub.payloads, 1)
+	})
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	// Given
+	stub := &webhookStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL, FormatGeneric)
+
+	// When
+	n.Notify(newTestTransition("a", validations.ObjectValid, validations.ObjectNeedsImprovement))
+	assert.NoError(t, n.flush(context.Background()))
+	n.Notify(newTestTransition("b", validations.ObjectValid, validations.ObjectNeedsImprovement))
+	assert.NoError(t, n.flush(context.Background()))
+
+	// Assert
+	assert.Len(t, stub.payloads, 1)
+	// the transition is kept for the next batch
+	assert.Len(t, n.pending, 1)
+}
+
+func TestNotifierTemplates(t *testing.T) {
+	batch := Batch{Transitions: []Transition{
+		newTestTransition("a", validations.ObjectValid, validations.ObjectNeedsImprovement),
+		newTestTransition("b", validations.ObjectNeedsImprovement, validations.ObjectValid),
+	}}
+	batch.Transitions[0].Team = "payments"
+	batch.Transitions[0].Contact = "#payments-oncall"
+
+	t.Run("slack", func(t *testing.T) {
+		tmpl, err := newTemplate(FormatSlack, "")
+		assert.NoError(t, err)
+
+		var payload struct {
+			Text string `json:"text"`
+		}
+		var sb strings.Builder
+		assert.NoError(t, tmpl.Execute(&sb, batch))
+		assert.NoError(t, json.Unmarshal([]byte(sb.String()), &payload))
+		assert.Contains(t, payload.Text, "1 object(s) newly failing, 1 object(s) fixed")
+		assert.Contains(t, payload.Text, "- failing: Deployment ns/a (team payments, #payments-oncall)")
+		assert.Contains(t, payload.Text, "- fixed: Deployment ns/b\n")
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		tmpl, err := newTemplate(FormatSlack, `{{ range .NewlyFailing }}{{ .Name }}{{ end }}`)
+		assert.NoError(t, err)
+
+		var sb strings.Builder
+		assert.NoError(t, tmpl.Execute(&sb, batch))
+		assert.Equal(t, "a", sb.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := newTemplate("unknown", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestIsTransition(t *testing.T) {
+	assert.True(t, IsTransition(validations.ObjectValid, validations.ObjectNeedsImprovement))
+	assert.True(t, IsTransition(validations.ObjectNeedsImprovement, validations.ObjectValid))
+	assert.False(t, IsTransition(validations.ObjectValid, validations.ObjectValid))
+	assert.False(t, IsTransition(validations.ObjectValidationIgnored, validations.ObjectNeedsImprovement))
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Payload formats supported out of the box
+const (
+	FormatGeneric = "generic"
+	FormatSlack   = "slack"
+	FormatTeams   = "teams"
+)
+
+// Transition describes an object whose validation outcome changed
+type Transition struct {
+	Kind      string                        `json:"kind"`
+	Name      string                        `json:"name"`
+	Namespace string                        `json:"namespace"`
+	UID       string                        `json:"uid"`
+	From      validations.ValidationOutcome `json:"from"`
+	To        validations.ValidationOutcome `json:"to"`
+	Time      time.Time                     `json:"time"`
+	// Team and Contact identify the team owning the object, if known
+	Team    string `json:"team,omitempty"`
+	Contact string `json:"contact,omitempty"`
+}
+
+// NewTransition returns the Transition of the given object between both outcomes
+func NewTransition(obj client.Object, from, to validations.ValidationOutcome) Transition {
+	return Transition{
+		Kind:      obj.GetObjectKind().GroupVersionKind().Kind,
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		UID:       string(obj.GetUID()),
+		From:      from,
+		To:        to,
+		Time:      time.Now().UTC(),
+	}
+}
+
+// IsTransition returns true if an object going from one outcome to the other
+// is worth a notification, i.e. it became valid or started needing improvement
+func IsTransition(from, to validations.ValidationOutcome) bool {
+	return (from == validations.ObjectValid && to == validations.ObjectNeedsImprovement) ||
+		(from == validations.ObjectNeedsImprovement && to == validations.ObjectValid)
+}
+
+// Batch is the data the payload templates are rendered with
+type Batch struct {
+	Transitions []Transition `json:"transitions"`
+}
+
+// NewlyFailing returns the transitions of the objects which started needing improvement
+func (b Batch) NewlyFailing() []Transition {
+	return b.filter(validations.ObjectNeedsImprovement)
+}
+
+// NewlyFixed returns the transitions of the objects which became valid
+func (b Batch) NewlyFixed() []Transition {
+	return b.filter(validations.ObjectValid)
+}
+
+func (b Batch) filter(to validations.ValidationOutcome) []Transition {
+	var transitions []Transition
+	for _, t := range b.Transitions {
+		if t.To == to {
+			transitions = append(transitions, t)
+		}
+	}
+	return transitions
+}
+
+// Text returns a markdown summary of the batch, suitable for chat messages
+func (b Batch) Text() string {
+	var sb strings.Builder
+
+	failing, fixed := b.NewlyFailing(), b.NewlyFixed()
+	fmt.Fprintf(&sb, "Deployment Validation Operator: %d object(s) newly failing, %d object(s) fixed\n",
+		len(failing), len(fixed))
+	for _, t := range failing {
+		fmt.Fprintf(&sb, "- failing: %s %s/%s%s\n", t.Kind, t.Namespace, t.Name, t.owner())
+	}
+	for _, t := range fixed {
+		fmt.Fprintf(&sb, "- fixed: %s %s/%s%s\n", t.Kind, t.Namespace, t.Name, t.owner())
+	}
+
+	return sb.String()
+}
+
+// owner returns the team owning the object, along with its contact, formatted
+// to be appended to a line of the summary, or an empty string if it is not known
+func (t Transition) owner() string {
+	switch {
+	case t.Team == "":
+		return ""
+	case t.Contact == "":
+		return fmt.Sprintf(" (team %s)", t.Team)
+	default:
+		return fmt.Sprintf(" (team %s, %s)", t.Team, t.Contact)
+	}
+}
+
+var formatTemplates = map[string]string{
+	FormatGeneric: `{{ json . }}`,
+	FormatSlack:   `{"text": {{ json .Text }}}`,
+	FormatTeams:   `{"text": {{ json .Text }}}`,
+}
+
+// newTemplate returns the given custom template, or the one of the given format
+func newTemplate(format, custom string) (*template.Template, error) {
+	text := custom
+	if text == "" {
+		if format == "" {
+			format = FormatGeneric
+		}
+
+		var ok bool
+		if text, ok = formatTemplates[format]; !ok {
+			return nil, fmt.Errorf("unknown notification format %q", format)
+		}
+	}
+
+	tmpl, err := template.New("payload").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing notification template: %w", err)
+	}
+
+	return tmpl, nil
+}
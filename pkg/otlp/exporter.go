@@ -0,0 +1,127 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/config"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// shutdownTimeout bounds the time spent flushing the pending signals when stopping
+const shutdownTimeout = 10 * time.Second
+
+// Options configures the OTLP exporter
+type Options struct {
+	// EndpointURL is the base URL of the OTLP/HTTP collector, e.g. http://collector:4318
+	EndpointURL string
+	// Headers are sent with every request, e.g. for authentication
+	Headers map[string]string
+	// Interval is the period between two exports of the metrics
+	Interval time.Duration
+}
+
+// Exporter pushes the metrics of a Prometheus registry and the details of
+// the validation failures, as log records, to an OTLP/HTTP endpoint
+type Exporter struct {
+	meterProvider  *sdkmetric.MeterProvider
+	loggerProvider *sdklog.LoggerProvider
+	logger         otellog.Logger
+}
+
+// NewExporter returns an Exporter sending the metrics gathered from the
+// given registry and the reported failures to the configured endpoint
+func NewExporter(ctx context.Context, gatherer prometheus.Gatherer, opts Options) (*Exporter, error) {
+	res := resource.NewSchemaless(attribute.String("service.name", config.OperatorName))
+	endpoint := strings.TrimSuffix(opts.EndpointURL, "/")
+
+	metricExporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"),
+		otlpmetrichttp.WithHeaders(opts.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("initializing OTLP metric exporter: %w", err)
+	}
+
+	logExporter, err := otlploghttp.New(ctx,
+		otlploghttp.WithEndpointURL(endpoint+"/v1/logs"),
+		otlploghttp.WithHeaders(opts.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("initializing OTLP log exporter: %w", err)
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter,
+			sdkmetric.WithInterval(opts.Interval),
+			sdkmetric.WithProducer(newGathererProducer(gatherer)),
+		)),
+	)
+
+	loggerProvider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+	)
+
+	return &Exporter{
+		meterProvider:  meterProvider,
+		loggerProvider: loggerProvider,
+		logger:         loggerProvider.Logger(config.OperatorName),
+	}, nil
+}
+
+// Start exports the signals until the context is cancelled, then flushes the pending ones
+func (e *Exporter) Start(ctx context.Context) error {
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := e.meterProvider.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down OTLP metric exporter: %w", err)
+	}
+	if err := e.loggerProvider.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down OTLP log exporter: %w", err)
+	}
+
+	return nil
+}
+
+// ReportFailures emits a log record for each of the given failures
+func (e *Exporter) ReportFailures(failures []validations.Failure) {
+	now := time.Now()
+
+	for _, f := range failures {
+		var record otellog.Record
+		record.SetTimestamp(now)
+		record.SetSeverity(otellog.SeverityWarn)
+		record.SetSeverityText("WARN")
+		record.SetBody(otellog.StringValue(f.Message))
+		record.AddAttributes(
+			otellog.String("check", f.Check),
+			otellog.String("kind", f.Kind),
+			otellog.String("name", f.Name),
+			otellog.String("namespace", f.Namespace),
+			otellog.String("uid", f.UID),
+		)
+		if f.Team != "" {
+			record.AddAttributes(otellog.String("team", f.Team))
+		}
+		if f.Contact != "" {
+			record.AddAttributes(otellog.String("contact", f.Contact))
+		}
+
+		e.logger.Emit(context.Background(), record)
+	}
+}
@@ -0,0 +1,168 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	common "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collectorStub is an in-process OTLP/HTTP collector recording the received signals
+type collectorStub struct {
+	mux     sync.Mutex
+	headers []http.Header
+	metrics []*colmetrics.ExportMetricsServiceRequest
+	logs    []*collogs.ExportLogsServiceRequest
+}
+
+func (c *collectorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.headers = append(c.headers, r.Header.Clone())
+	switch r.URL.Path {
+	case "/v1/metrics":
+		req := &colmetrics.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.metrics = append(c.metrics, req)
+	case "/v1/logs":
+		req := &collogs.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.logs = append(c.logs, req)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+// gaugeValues returns the values of the data points of the named metric, by value of the given attribute
+func (c *collectorStub) gaugeValues(name, attribute string) map[string]float64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	values := map[string]float64{}
+	for _, req := range c.metrics {
+		for _, rm := range req.GetResourceMetrics() {
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					if m.GetName() != name {
+						continue
+					}
+					for _, dp := range m.GetGauge().GetDataPoints() {
+						values[attributeValue(dp.GetAttributes(), attribute)] = dp.GetAsDouble()
+					}
+				}
+			}
+		}
+	}
+	return values
+}
+
+// logBodies returns the bodies of the received log records, by value of the given attribute
+func (c *collectorStub) logBodies(attribute string) map[string]string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	bodies := map[string]string{}
+	for _, req := range c.logs {
+		for _, rl := range req.GetResourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, lr := range sl.GetLogRecords() {
+					key := attributeValue(lr.GetAttributes(), attribute)
+					bodies[key] = lr.GetBody().GetStringValue()
+				}
+			}
+		}
+	}
+	return bodies
+}
+
+func attributeValue(attributes []*common.KeyValue, key string) string {
+	for _, kv := range attributes {
+		if kv.GetKey() == key {
+			return kv.GetValue().GetStringValue()
+		}
+	}
+	return ""
+}
+
+func TestExporter(t *testing.T) {
+	// Given
+	collector := &collectorStub{}
+	srv := httptest.NewServer(collector)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "deployment_validation_operator_host_pid",
+		Help: "host-pid check",
+	}, []string{"name"})
+	assert.NoError(t, reg.Register(gauge))
+	gauge.WithLabelValues("app").Set(1)
+
+	exporter, err := NewExporter(context.Background(), reg, Options{
+		EndpointURL: srv.URL + "/",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Interval:    time.Hour,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- exporter.Start(ctx)
+	}()
+
+	// When
+	exporter.ReportFailures([]validations.Failure{{
+		Check:     "host-pid",
+		Kind:      "Deployment",
+		Name:      "app",
+		Namespace: "ns",
+		UID:       "app-uid",
+		Message:   "object shares the host's process namespace (via hostPID=true).",
+		Team:      "payments",
+		Contact:   "#payments-oncall",
+	}})
+
+	// stopping the exporter flushes the pending signals
+	cancel()
+	assert.NoError(t, <-errCh)
+
+	// Assert
+	assert.Equal(t, map[string]float64{"app": 1},
+		collector.gaugeValues("deployment_validation_operator_host_pid", "name"))
+	assert.Equal(t, map[string]string{"host-pid": "object shares the host's process namespace (via hostPID=true)."},
+		collector.logBodies("check"))
+	assert.Contains(t, collector.logBodies("team"), "payments")
+	assert.Contains(t, collector.logBodies("contact"), "#payments-oncall")
+	for _, h := range collector.headers {
+		assert.Equal(t, "Bearer token", h.Get("Authorization"))
+	}
+}
@@ -0,0 +1,90 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/config"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// gathererProducer converts the gauges and counters of a Prometheus registry
+// into OpenTelemetry metrics, so both exporters publish the same signals.
+// Other metric types, only used by the runtime collectors, are skipped.
+type gathererProducer struct {
+	gatherer  prometheus.Gatherer
+	startTime time.Time
+}
+
+func newGathererProducer(gatherer prometheus.Gatherer) *gathererProducer {
+	return &gathererProducer{
+		gatherer:  gatherer,
+		startTime: time.Now(),
+	}
+}
+
+// Produce implements the sdkmetric.Producer interface
+func (p *gathererProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("gathering prometheus metrics: %w", err)
+	}
+
+	now := time.Now()
+	metrics := make([]metricdata.Metrics, 0, len(families))
+	for _, family := range families {
+		switch family.GetType() {
+		case dto.MetricType_GAUGE:
+			gauge := metricdata.Gauge[float64]{}
+			for _, m := range family.GetMetric() {
+				gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: toAttributes(m.GetLabel()),
+					Time:       now,
+					Value:      m.GetGauge().GetValue(),
+				})
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        family.GetName(),
+				Description: family.GetHelp(),
+				Data:        gauge,
+			})
+
+		case dto.MetricType_COUNTER:
+			sum := metricdata.Sum[float64]{
+				Temporality: metricdata.CumulativeTemporality,
+				IsMonotonic: true,
+			}
+			for _, m := range family.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: toAttributes(m.GetLabel()),
+					StartTime:  p.startTime,
+					Time:       now,
+					Value:      m.GetCounter().GetValue(),
+				})
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        family.GetName(),
+				Description: family.GetHelp(),
+				Data:        sum,
+			})
+		}
+	}
+
+	return []metricdata.ScopeMetrics{{
+		Scope:   instrumentation.Scope{Name: config.OperatorName},
+		Metrics: metrics,
+	}}, nil
+}
+
+func toAttributes(labels []*dto.LabelPair) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(labels))
+	for _, l := range labels {
+		kvs = append(kvs, attribute.String(l.GetName(), l.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
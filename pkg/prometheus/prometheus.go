@@ -48,6 +48,7 @@ func NewServer(registry Registry, path, addr string) (*Server, error) {
 	}
 
 	return &Server{
+		mux: mux,
 		s: &http.Server{
 			Addr:              addr,
 			Handler:           mux,
@@ -105,11 +106,13 @@ func getRouter(registry Registry, path string) (*http.ServeMux, error) {
 //
 // Parameters:
 //   - pr: A pointer to a Prometheus registry where the metrics will be registered.
+//   - labels: The object and namespace metadata promoted to labels of the metrics.
 //
 // Returns:
 //   - A map of check names to corresponding GaugeVec metrics.
 //   - An error if any error occurs during metric setup or registration.
-func PreloadMetrics(pr *prometheus.Registry) (map[string]*prometheus.GaugeVec, error) {
+func PreloadMetrics(pr *prometheus.Registry,
+	labels validations.MetricLabelsConfig) (map[string]*prometheus.GaugeVec, error) {
 	preloadedMetrics := make(map[string]*prometheus.GaugeVec)
 
 	klr, err := validations.GetKubeLinterRegistry()
@@ -123,7 +126,7 @@ func PreloadMetrics(pr *prometheus.Registry) (map[string]*prometheus.GaugeVec, e
 	}
 
 	for _, checkName := range checks {
-		metric, err := setupMetric(klr, checkName)
+		metric, err := setupMetric(klr, checkName, labels)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create metric for check %s", checkName)
 		}
@@ -140,7 +143,8 @@ func PreloadMetrics(pr *prometheus.Registry) (map[string]*prometheus.GaugeVec, e
 
 // setupMetric sets up a Prometheus metric based on the provided checkname and information from a CheckRegistry.
 // The metric is created with the formatted name, description, and remediation information from the check specification.
-func setupMetric(reg checkregistry.CheckRegistry, name string) (*prometheus.GaugeVec, error) {
+func setupMetric(reg checkregistry.CheckRegistry, name string,
+	labels validations.MetricLabelsConfig) (*prometheus.GaugeVec, error) {
 	check := reg.Load(name)
 	if check == nil {
 		return nil, fmt.Errorf("unable to create metric for check %s", name)
@@ -160,11 +164,18 @@ func setupMetric(reg checkregistry.CheckRegistry, name string) (*prometheus.Gaug
 				"check_description": check.Spec.Description,
 				"check_remediation": check.Spec.Remediation,
 			},
-		}, []string{"namespace_uid", "namespace", "uid", "name", "kind"}), nil
+		}, validations.MetricLabelNames(labels)), nil
 }
 
 type Server struct {
-	s *http.Server
+	mux *http.ServeMux
+	s   *http.Server
+}
+
+// Handle registers an additional handler for the given pattern
+// It must be called before the server is started
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
 }
 
 func (s *Server) Start(ctx context.Context) error {
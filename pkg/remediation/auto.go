@@ -0,0 +1,350 @@
+package remediation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/config"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// FieldManager is the field manager of the fields set by the automatic remediation
+const FieldManager = config.OperatorName + "-remediation"
+
+// autoAPIVersions are the API versions of the kinds remediated automatically.
+// Pods are not remediated as the fields patched are immutable once created.
+var autoAPIVersions = map[string]string{
+	"CronJob":          "batch/v1",
+	"Deployment":       "apps/v1",
+	"DeploymentConfig": "apps.openshift.io/v1",
+	"DaemonSet":        "apps/v1",
+	"Job":              "batch/v1",
+	"ReplicaSet":       "apps/v1",
+	"StatefulSet":      "apps/v1",
+}
+
+// Defaults are the resources set by the automatic remediation of the
+// unset-cpu-requirements and unset-memory-requirements checks, in the
+// format of the container limits of a LimitRange
+type Defaults struct {
+	// DefaultRequest are the requests set on the containers
+	DefaultRequest corev1.ResourceList `json:"defaultRequest"`
+	// Default are the limits set on the containers
+	Default corev1.ResourceList `json:"default"`
+}
+
+// builtinDefaults are the resources set when not defined by the defaults file
+var builtinDefaults = Defaults{
+	DefaultRequest: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	},
+	Default: corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	},
+}
+
+// LoadDefaults reads the defaults from the given YAML file
+func LoadDefaults(path string) (Defaults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Defaults{}, fmt.Errorf("reading remediation defaults: %w", err)
+	}
+
+	var defaults Defaults
+	if err := yaml.Unmarshal(data, &defaults); err != nil {
+		return Defaults{}, fmt.Errorf("parsing remediation defaults: %w", err)
+	}
+	return defaults, nil
+}
+
+// withBuiltins returns the defaults, the resources not defined being set to the built-in defaults
+func (d Defaults) withBuiltins() Defaults {
+	merged := Defaults{DefaultRequest: corev1.ResourceList{}, Default: corev1.ResourceList{}}
+	for _, list := range []struct{ dst, builtin, set corev1.ResourceList }{
+		{merged.DefaultRequest, builtinDefaults.DefaultRequest, d.DefaultRequest},
+		{merged.Default, builtinDefaults.Default, d.Default},
+	} {
+		for name, quantity := range list.builtin {
+			list.dst[name] = quantity
+		}
+		for name, quantity := range list.set {
+			list.dst[name] = quantity
+		}
+	}
+	return merged
+}
+
+// fields returns the container fields fixing the given check
+func (d Defaults) fields(check string) map[string]interface{} {
+	switch check {
+	case "unset-cpu-requirements":
+		cpu := d.DefaultRequest[corev1.ResourceCPU]
+		return map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"cpu": cpu.String()},
+			},
+		}
+	case "unset-memory-requirements":
+		request := d.DefaultRequest[corev1.ResourceMemory]
+		limit := d.Default[corev1.ResourceMemory]
+		return map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"memory": request.String()},
+				"limits":   map[string]interface{}{"memory": limit.String()},
+			},
+		}
+	default:
+		return runtime.DeepCopyJSON(fixes[check].fields)
+	}
+}
+
+// AutoOptions configures the automatic remediation
+type AutoOptions struct {
+	// Checks are the checks remediated automatically
+	Checks []string
+	// NamespaceSelector selects the namespaces opted in by their labels
+	NamespaceSelector string
+	// Defaults are the resources set on the containers
+	Defaults Defaults
+	// DryRun only validates the patches with a server-side dry-run
+	DryRun bool
+	// AuditLog is the path of the file the audit entries are appended to
+	AuditLog string
+}
+
+// AuditEntry records a patch applied, or attempted, by the automatic remediation
+type AuditEntry struct {
+	Time         time.Time       `json:"time"`
+	Check        string          `json:"check"`
+	Kind         string          `json:"kind"`
+	Namespace    string          `json:"namespace"`
+	Name         string          `json:"name"`
+	UID          string          `json:"uid"`
+	Container    string          `json:"container"`
+	FieldManager string          `json:"fieldManager"`
+	Patch        json.RawMessage `json:"patch"`
+	DryRun       bool            `json:"dryRun"`
+	Applied      bool            `json:"applied"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// AutoRemediator applies the fixes of the allowed checks to the objects of the
+// namespaces opted in, with server-side apply. Each patch is validated with a
+// dry-run before being applied, and every attempt is recorded in the audit log.
+type AutoRemediator struct {
+	client   client.Client
+	logger   logr.Logger
+	checks   map[string]struct{}
+	selector labels.Selector
+	defaults Defaults
+	dryRun   bool
+	auditLog string
+	// attempted records the resource version of the objects last patched,
+	// by object, check and container, not to patch an unchanged object again
+	attempted map[string]string
+}
+
+// NewAutoRemediator returns an AutoRemediator, after checking each check can be remediated.
+// The resources not set by the defaults are set to the built-in defaults.
+func NewAutoRemediator(c client.Client, logger logr.Logger, opts AutoOptions) (*AutoRemediator, error) {
+	checks := make(map[string]struct{}, len(opts.Checks))
+	for _, check := range opts.Checks {
+		if _, ok := fixes[check]; !ok {
+			return nil, fmt.Errorf("check %q cannot be remediated automatically", check)
+		}
+		checks[check] = struct{}{}
+	}
+	if len(checks) == 0 {
+		return nil, errors.New("no check to remediate automatically")
+	}
+
+	if opts.NamespaceSelector == "" {
+		return nil, errors.New("a namespace selector is required for the automatic remediation")
+	}
+	selector, err := labels.Parse(opts.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing namespace selector: %w", err)
+	}
+
+	return &AutoRemediator{
+		client:    c,
+		logger:    logger,
+		checks:    checks,
+		selector:  selector,
+		defaults:  opts.Defaults.withBuiltins(),
+		dryRun:    opts.DryRun,
+		auditLog:  opts.AuditLog,
+		attempted: map[string]string{},
+	}, nil
+}
+
+// Remediate patches the objects failing the allowed checks in the namespaces
+// whose labels, given by namespace name, match the selector
+func (r *AutoRemediator) Remediate(ctx context.Context, results []validations.CheckResult,
+	namespaceLabels map[string]map[string]string) {
+	selected := map[string]struct{}{}
+	for _, result := range results {
+		if !r.selected(result.Failure, namespaceLabels) {
+			continue
+		}
+		selected[attemptKey(result.Failure)] = struct{}{}
+		if entry, ok := r.remediate(ctx, result.Failure); ok {
+			r.audit(entry)
+		}
+	}
+
+	for key := range r.attempted {
+		if _, ok := selected[key]; !ok {
+			delete(r.attempted, key)
+		}
+	}
+}
+
+func attemptKey(f validations.Failure) string {
+	return fmt.Sprintf("%s/%s/%s", f.UID, f.Check, f.Container)
+}
+
+func (r *AutoRemediator) selected(f validations.Failure, namespaceLabels map[string]map[string]string) bool {
+	if _, ok := r.checks[f.Check]; !ok {
+		return false
+	}
+	if _, ok := autoAPIVersions[f.Kind]; !ok || f.Container == "" {
+		return false
+	}
+	nsLabels, ok := namespaceLabels[f.Namespace]
+	return ok && r.selector.Matches(labels.Set(nsLabels))
+}
+
+// remediate applies the fix of the failure, returning false when the object was not patched
+func (r *AutoRemediator) remediate(ctx context.Context, f validations.Failure) (AuditEntry, bool) {
+	patch, ok := containerPatch(f, r.defaults.fields(f.Check))
+	if !ok {
+		return AuditEntry{}, false
+	}
+
+	live := &unstructured.Unstructured{}
+	live.SetAPIVersion(autoAPIVersions[f.Kind])
+	live.SetKind(f.Kind)
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: f.Namespace, Name: f.Name}, live); err != nil {
+		r.logger.Error(err, "getting object to remediate", "kind", f.Kind,
+			"namespace", f.Namespace, "name", f.Name)
+		return AuditEntry{}, false
+	}
+	if string(live.GetUID()) != f.UID {
+		// the object was replaced since it was validated
+		return AuditEntry{}, false
+	}
+	if owner := metav1.GetControllerOf(live); owner != nil {
+		// the changes would be reverted by the controller, which is remediated instead
+		return AuditEntry{}, false
+	}
+
+	key := attemptKey(f)
+	if r.attempted[key] == live.GetResourceVersion() {
+		return AuditEntry{}, false
+	}
+	r.attempted[key] = live.GetResourceVersion()
+
+	entry := AuditEntry{
+		Time:         time.Now().UTC(),
+		Check:        f.Check,
+		Kind:         f.Kind,
+		Namespace:    f.Namespace,
+		Name:         f.Name,
+		UID:          f.UID,
+		Container:    f.Container,
+		FieldManager: FieldManager,
+		DryRun:       r.dryRun,
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, true
+	}
+	entry.Patch = data
+
+	// the fields owned by the field manager are applied again along with the
+	// fix, as server-side apply removes the owned fields left out
+	owned, err := ownedFields(live, FieldManager)
+	if err != nil {
+		entry.Error = fmt.Sprintf("extracting owned fields: %s", err)
+		return entry, true
+	}
+	mergeFields(owned, patch)
+
+	obj := &unstructured.Unstructured{Object: owned}
+	obj.SetAPIVersion(live.GetAPIVersion())
+	obj.SetKind(live.GetKind())
+	obj.SetNamespace(live.GetNamespace())
+	obj.SetName(live.GetName())
+	applyConfig := client.ApplyConfigurationFromUnstructured(obj)
+
+	err = r.client.Apply(ctx, applyConfig, client.FieldOwner(FieldManager), client.DryRunAll)
+	if err != nil {
+		entry.Error = fmt.Sprintf("dry-run: %s", err)
+		return entry, true
+	}
+	if r.dryRun {
+		return entry, true
+	}
+
+	if err := r.client.Apply(ctx, applyConfig, client.FieldOwner(FieldManager)); err != nil {
+		entry.Error = err.Error()
+		return entry, true
+	}
+	entry.Applied = true
+	return entry, true
+}
+
+// audit logs the entry, and appends it to the audit log file if any
+func (r *AutoRemediator) audit(entry AuditEntry) {
+	keysAndValues := []interface{}{
+		"check", entry.Check, "kind", entry.Kind, "namespace", entry.Namespace,
+		"name", entry.Name, "container", entry.Container, "patch", string(entry.Patch),
+		"dryRun", entry.DryRun, "applied", entry.Applied,
+	}
+	if entry.Error != "" {
+		r.logger.Error(errors.New(entry.Error), "automatic remediation failed", keysAndValues...)
+	} else {
+		r.logger.Info("automatic remediation", keysAndValues...)
+	}
+
+	if r.auditLog == "" {
+		return
+	}
+	if err := appendAuditEntry(r.auditLog, entry); err != nil {
+		r.logger.Error(err, "writing remediation audit log", "path", r.auditLog)
+	}
+}
+
+func appendAuditEntry(path string, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,286 @@
+package remediation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const optInLabel = "deployment-validation-operator.openshift.io/auto-remediation=true"
+
+func testDeployment(namespace string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace, UID: types.UID("uid-" + namespace)},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "sidecar", Image: "sidecar"},
+					{Name: "app", Image: "app"},
+				}},
+			},
+		},
+	}
+}
+
+func testFailure(check, namespace string) validations.CheckResult {
+	return validations.CheckResult{Failure: validations.Failure{
+		Check: check, Kind: "Deployment", Name: "app", Namespace: namespace, UID: "uid-" + namespace,
+		Container: "app", ContainerPath: "containers[1]",
+	}}
+}
+
+// applyRecorder records the objects applied, the fake client not supporting
+// the server-side apply of the fields of built-in types left out
+type applyRecorder struct {
+	objects []string
+	dryRun  []bool
+	err     error
+}
+
+func (a *applyRecorder) client(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).
+		WithReturnManagedFields().WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(_ context.Context, _ client.WithWatch, obj runtime.ApplyConfiguration,
+			opts ...client.ApplyOption) error {
+			applyOpts := &client.ApplyOptions{}
+			applyOpts.ApplyOptions(opts)
+			if applyOpts.FieldManager != FieldManager {
+				return errors.New("unexpected field manager")
+			}
+
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			a.objects = append(a.objects, string(data))
+			a.dryRun = append(a.dryRun, len(applyOpts.DryRun) > 0)
+			return a.err
+		},
+	}).Build()
+}
+
+func newTestRemediator(t *testing.T, c client.Client, opts AutoOptions) *AutoRemediator {
+	opts.NamespaceSelector = optInLabel
+	r, err := NewAutoRemediator(c, logr.Discard(), opts)
+	assert.NoError(t, err)
+	return r
+}
+
+func TestAutoRemediator(t *testing.T) {
+	namespaceLabels := map[string]map[string]string{
+		"opted-in": {"deployment-validation-operator.openshift.io/auto-remediation": "true"},
+		"other":    {},
+	}
+
+	t.Run("the fixes are applied along the fields previously applied", func(t *testing.T) {
+		// Given
+		remediated := testDeployment("opted-in")
+		remediated.Spec.Template.Spec.Containers[1].Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("50m"),
+		}
+		remediated.ManagedFields = []metav1.ManagedFieldsEntry{{
+			Manager: FieldManager, Operation: metav1.ManagedFieldsOperationApply,
+			APIVersion: "apps/v1", FieldsType: "FieldsV1",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{` +
+				`"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:name":{},` +
+				`"f:resources":{"f:requests":{"f:cpu":{}}}}}}}}}`)},
+		}}
+		applies := &applyRecorder{}
+		c := applies.client(remediated, testDeployment("other"))
+		auditLog := filepath.Join(t.TempDir(), "audit.log")
+		r := newTestRemediator(t, c, AutoOptions{
+			Checks: []string{"unset-cpu-requirements", "unset-memory-requirements"},
+			Defaults: Defaults{
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+			},
+			AuditLog: auditLog,
+		})
+
+		// When
+		r.Remediate(context.Background(), []validations.CheckResult{
+			testFailure("unset-memory-requirements", "opted-in"),
+			testFailure("unset-memory-requirements", "other"),
+		}, namespaceLabels)
+
+		// Assert
+		assert.Equal(t, []bool{true, false}, applies.dryRun)
+		for _, obj := range applies.objects {
+			assert.JSONEq(t, `{"apiVersion":"apps/v1","kind":"Deployment",`+
+				`"metadata":{"name":"app","namespace":"opted-in"},`+
+				`"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{`+
+				`"requests":{"cpu":"50m","memory":"128Mi"},"limits":{"memory":"256Mi"}}}]}}}}`, obj)
+		}
+
+		data, err := os.ReadFile(auditLog)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		assert.Len(t, lines, 1)
+		var entry AuditEntry
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "unset-memory-requirements", entry.Check)
+		assert.Equal(t, "opted-in", entry.Namespace)
+		assert.Equal(t, "app", entry.Container)
+		assert.Equal(t, FieldManager, entry.FieldManager)
+		assert.JSONEq(t, `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{`+
+			`"requests":{"memory":"128Mi"},"limits":{"memory":"256Mi"}}}]}}}}`, string(entry.Patch))
+		assert.True(t, entry.Applied)
+		assert.Empty(t, entry.Error)
+	})
+
+	t.Run("the patches are only validated in dry-run mode", func(t *testing.T) {
+		// Given
+		applies := &applyRecorder{}
+		c := applies.client(testDeployment("opted-in"))
+		auditLog := filepath.Join(t.TempDir(), "audit.log")
+		r := newTestRemediator(t, c, AutoOptions{
+			Checks:   []string{"run-as-non-root"},
+			DryRun:   true,
+			AuditLog: auditLog,
+		})
+
+		// When
+		results := []validations.CheckResult{testFailure("run-as-non-root", "opted-in")}
+		r.Remediate(context.Background(), results, namespaceLabels)
+		r.Remediate(context.Background(), results, namespaceLabels)
+
+		// Assert
+		assert.Equal(t, []bool{true}, applies.dryRun, "the unchanged object is not patched again")
+
+		data, err := os.ReadFile(auditLog)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		assert.Len(t, lines, 1)
+		var entry AuditEntry
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.True(t, entry.DryRun)
+		assert.False(t, entry.Applied)
+		assert.JSONEq(t, `{"spec":{"template":{"spec":{"containers":[`+
+			`{"name":"app","securityContext":{"runAsNonRoot":true}}]}}}}`, string(entry.Patch))
+	})
+
+	t.Run("the patches failing the dry-run are not applied", func(t *testing.T) {
+		// Given
+		applies := &applyRecorder{err: errors.New("conflict")}
+		c := applies.client(testDeployment("opted-in"))
+		r := newTestRemediator(t, c, AutoOptions{Checks: []string{"run-as-non-root"}})
+
+		// When
+		r.Remediate(context.Background(), []validations.CheckResult{
+			testFailure("run-as-non-root", "opted-in"),
+		}, namespaceLabels)
+
+		// Assert
+		assert.Equal(t, []bool{true}, applies.dryRun)
+	})
+
+	t.Run("the checks not allowed and the objects owned by a controller are not remediated", func(t *testing.T) {
+		// Given
+		owned := testDeployment("opted-in")
+		controller := true
+		owned.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: "v1", Kind: "Owner", Name: "owner", UID: "owner", Controller: &controller},
+		}
+		applies := &applyRecorder{}
+		c := applies.client(owned)
+		r := newTestRemediator(t, c, AutoOptions{Checks: []string{"unset-cpu-requirements"}})
+
+		// When
+		r.Remediate(context.Background(), []validations.CheckResult{
+			testFailure("unset-cpu-requirements", "opted-in"),
+			testFailure("run-as-non-root", "opted-in"),
+		}, namespaceLabels)
+
+		// Assert
+		assert.Empty(t, applies.objects)
+	})
+
+	t.Run("the checks without fix are rejected", func(t *testing.T) {
+		// When
+		_, err := NewAutoRemediator(nil, logr.Discard(), AutoOptions{
+			Checks: []string{"latest-tag"}, NamespaceSelector: optInLabel,
+		})
+
+		// Assert
+		assert.ErrorContains(t, err, `check "latest-tag" cannot be remediated automatically`)
+	})
+}
+
+func TestOwnedFields(t *testing.T) {
+	// Given
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "sidecar", "image": "sidecar"},
+					map[string]interface{}{
+						"name":  "app",
+						"image": "app",
+						"resources": map[string]interface{}{
+							"requests": map[string]interface{}{"cpu": "100m"},
+						},
+					},
+				},
+			}},
+		},
+	}}
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, FieldsType: "FieldsV1",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+		{Manager: FieldManager, Operation: metav1.ManagedFieldsOperationApply, FieldsType: "FieldsV1",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{` +
+				`"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:name":{},` +
+				`"f:resources":{"f:requests":{"f:cpu":{}}}}}}}}}`)}},
+	})
+
+	// When
+	owned, err := ownedFields(obj, FieldManager)
+	assert.NoError(t, err)
+	mergeFields(owned, map[string]interface{}{"spec": map[string]interface{}{
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "resources": map[string]interface{}{
+					"requests": map[string]interface{}{"memory": "128Mi"},
+				}},
+				map[string]interface{}{"name": "sidecar", "securityContext": map[string]interface{}{
+					"runAsNonRoot": true,
+				}},
+			},
+		}},
+	}})
+
+	// Assert
+	assert.Equal(t, map[string]interface{}{"spec": map[string]interface{}{
+		"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+				}},
+				map[string]interface{}{"name": "sidecar", "securityContext": map[string]interface{}{
+					"runAsNonRoot": true,
+				}},
+			},
+		}},
+	}}, owned)
+}
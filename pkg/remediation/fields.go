@@ -0,0 +1,162 @@
+package remediation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ownedFields returns the fields of the object applied by the given field manager,
+// with their current values, as read from the managed fields of the object
+func ownedFields(obj *unstructured.Unstructured, manager string) (map[string]interface{}, error) {
+	owned := map[string]interface{}{}
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager != manager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, fmt.Errorf("parsing managed fields: %w", err)
+		}
+		if extracted, ok := extractFields(fields, obj.Object).(map[string]interface{}); ok {
+			mergeFields(owned, extracted)
+		}
+	}
+	delete(owned, "apiVersion")
+	delete(owned, "kind")
+	return owned, nil
+}
+
+// extractFields returns the values of the given fields, in the FieldsV1 format,
+// from the given value. Fields are prefixed with f:, list items are identified
+// by their keys with k: and by their value with v:. Leaf fields are copied as is.
+func extractFields(fields map[string]interface{}, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		extracted := map[string]interface{}{}
+		for key, sub := range fields {
+			name, ok := strings.CutPrefix(key, "f:")
+			if !ok {
+				continue
+			}
+			fieldValue, ok := v[name]
+			if !ok {
+				continue
+			}
+			if subFields, _ := sub.(map[string]interface{}); len(subFields) > 0 {
+				extracted[name] = extractFields(subFields, fieldValue)
+			} else {
+				extracted[name] = fieldValue
+			}
+		}
+		return extracted
+	case []interface{}:
+		extracted := []interface{}{}
+		for key, sub := range fields {
+			subFields, _ := sub.(map[string]interface{})
+			if keys, ok := strings.CutPrefix(key, "k:"); ok {
+				if item, ok := extractKeyedItem(keys, subFields, v); ok {
+					extracted = append(extracted, item)
+				}
+			} else if setValue, ok := strings.CutPrefix(key, "v:"); ok {
+				var item interface{}
+				if json.Unmarshal([]byte(setValue), &item) == nil && containsValue(v, item) {
+					extracted = append(extracted, item)
+				}
+			}
+		}
+		return extracted
+	default:
+		return value
+	}
+}
+
+// extractKeyedItem returns the fields of the item of the list identified by the given keys,
+// along with its keys
+func extractKeyedItem(keys string, fields map[string]interface{}, list []interface{}) (interface{}, bool) {
+	var keyValues map[string]interface{}
+	if err := json.Unmarshal([]byte(keys), &keyValues); err != nil {
+		return nil, false
+	}
+
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok || !matchesKeys(itemMap, keyValues) {
+			continue
+		}
+		extracted, _ := extractFields(fields, itemMap).(map[string]interface{})
+		for k := range keyValues {
+			extracted[k] = itemMap[k]
+		}
+		return extracted, true
+	}
+	return nil, false
+}
+
+func matchesKeys(item, keyValues map[string]interface{}) bool {
+	for k, v := range keyValues {
+		// the numbers are decoded as float64 from the keys, and int64 from the object
+		if fmt.Sprint(item[k]) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if fmt.Sprint(item) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFields merges the src fields into dst. The items of the lists are merged
+// by name, as the containers, the other items being appended if missing.
+func mergeFields(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		switch s := srcValue.(type) {
+		case map[string]interface{}:
+			if d, ok := dst[key].(map[string]interface{}); ok {
+				mergeFields(d, s)
+				continue
+			}
+		case []interface{}:
+			if d, ok := dst[key].([]interface{}); ok {
+				dst[key] = mergeLists(d, s)
+				continue
+			}
+		}
+		dst[key] = srcValue
+	}
+}
+
+func mergeLists(dst, src []interface{}) []interface{} {
+	for _, srcItem := range src {
+		merged := false
+		for _, dstItem := range dst {
+			d, dok := dstItem.(map[string]interface{})
+			s, sok := srcItem.(map[string]interface{})
+			switch {
+			case dok && sok && d["name"] != nil && d["name"] == s["name"]:
+				mergeFields(d, s)
+				merged = true
+			case !dok && !sok && fmt.Sprint(dstItem) == fmt.Sprint(srcItem):
+				merged = true
+			}
+			if merged {
+				break
+			}
+		}
+		if !merged {
+			dst = append(dst, srcItem)
+		}
+	}
+	return dst
+}
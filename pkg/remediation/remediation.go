@@ -0,0 +1,180 @@
+package remediation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// StrategicMergePatchType is the type of the suggested patches
+const StrategicMergePatchType = "strategic"
+
+// Suggestion is a patch fixing a failing check, to be reviewed and applied by
+// the owner of the object
+type Suggestion struct {
+	validations.Failure
+	// Description explains the patch and the values to adjust
+	Description string `json:"description"`
+	// PatchType is the type of patch, as accepted by kubectl patch --type
+	PatchType string `json:"patchType"`
+	// Patch is the patch to apply to the object
+	Patch json.RawMessage `json:"patch"`
+	// Command is the kubectl command applying the patch
+	Command string `json:"command"`
+}
+
+// fix is the container fields fixing a check, and their description
+type fix struct {
+	fields      map[string]interface{}
+	description string
+}
+
+// fixes are the fixes of the checks patches are suggested for, by check name
+var fixes = map[string]fix{
+	"unset-cpu-requirements": {
+		fields: map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"cpu": "100m"},
+			},
+		},
+		description: "Set a CPU request on the container. " +
+			"The value is a placeholder to adjust to the actual usage of the container.",
+	},
+	"unset-memory-requirements": {
+		fields: map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"memory": "128Mi"},
+				"limits":   map[string]interface{}{"memory": "128Mi"},
+			},
+		},
+		description: "Set a memory request and limit on the container. " +
+			"The values are placeholders to adjust to the actual usage of the container.",
+	},
+	"run-as-non-root": {
+		fields: securityContext("runAsNonRoot", true),
+		description: "Require the container to run as a non-root user. " +
+			"The image must define a non-root user, or runAsUser must be set as well.",
+	},
+	"no-read-only-root-fs": {
+		fields: securityContext("readOnlyRootFilesystem", true),
+		description: "Mount the root filesystem of the container as read-only. " +
+			"The paths the container writes to must be mounted as volumes, e.g. emptyDir.",
+	},
+	"privilege-escalation-container": {
+		fields:      securityContext("allowPrivilegeEscalation", false),
+		description: "Prevent the processes of the container from gaining more privileges than their parent.",
+	},
+	"privileged-container": {
+		fields:      securityContext("privileged", false),
+		description: "Do not run the container in privileged mode.",
+	},
+}
+
+func securityContext(field string, value bool) map[string]interface{} {
+	return map[string]interface{}{
+		"securityContext": map[string]interface{}{field: value},
+	}
+}
+
+// podSpecPaths are the paths of the pod spec in the objects of each kind. Pods are not
+// patched, as the resources and the security context of their containers are immutable.
+var podSpecPaths = map[string][]string{
+	"CronJob":          {"spec", "jobTemplate", "spec", "template", "spec"},
+	"Deployment":       {"spec", "template", "spec"},
+	"DeploymentConfig": {"spec", "template", "spec"},
+	"DaemonSet":        {"spec", "template", "spec"},
+	"Job":              {"spec", "template", "spec"},
+	"ReplicaSet":       {"spec", "template", "spec"},
+	"StatefulSet":      {"spec", "template", "spec"},
+}
+
+// Suggest returns the patches fixing the given failures, for the checks and
+// kinds supported. Failures not referring to a container are skipped.
+func Suggest(results []validations.CheckResult) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, result := range results {
+		if s, ok := suggest(result.Failure); ok {
+			suggestions = append(suggestions, s)
+		}
+	}
+	return suggestions
+}
+
+func suggest(f validations.Failure) (Suggestion, bool) {
+	fix, ok := fixes[f.Check]
+	if !ok {
+		return Suggestion{}, false
+	}
+	patch, ok := containerPatch(f, fix.fields)
+	if !ok {
+		return Suggestion{}, false
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return Suggestion{}, false
+	}
+
+	return Suggestion{
+		Failure:     f,
+		Description: fix.description,
+		PatchType:   StrategicMergePatchType,
+		Patch:       data,
+		Command: fmt.Sprintf("kubectl patch %s %s -n %s --type %s -p '%s'",
+			strings.ToLower(f.Kind), f.Name, f.Namespace, StrategicMergePatchType, data),
+	}, true
+}
+
+// containerPatch returns the patch setting the given fields on the container
+// the failure refers to, nested in the pod spec of the object
+func containerPatch(f validations.Failure, fields map[string]interface{}) (map[string]interface{}, bool) {
+	path, ok := podSpecPaths[f.Kind]
+	if !ok || f.Container == "" {
+		return nil, false
+	}
+
+	container := map[string]interface{}{"name": f.Container}
+	for k, v := range fields {
+		container[k] = v
+	}
+
+	containersField := "containers"
+	if strings.HasPrefix(f.ContainerPath, "initContainers") {
+		containersField = "initContainers"
+	}
+	patch := map[string]interface{}{containersField: []interface{}{container}}
+	for i := len(path) - 1; i >= 0; i-- {
+		patch = map[string]interface{}{path[i]: patch}
+	}
+	return patch, true
+}
+
+// Handler serves the patches suggested for the checks currently failing as JSON.
+// The suggestions can be filtered with the namespace and check query parameters.
+// They are derived on each request from the results of the engine, i.e. the failures
+// recorded by the latest validation of each object, rather than stored along them.
+func Handler(engine validations.Interface) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.URL.Query().Get("namespace")
+		check := r.URL.Query().Get("check")
+
+		var results []validations.CheckResult
+		for _, result := range engine.GetResults() {
+			if namespace != "" && result.Namespace != namespace {
+				continue
+			}
+			if check != "" && result.Check != check {
+				continue
+			}
+			results = append(results, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(Suggest(results)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package remediation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestSuggest(t *testing.T) {
+	failure := func(check, kind, container, path string) validations.CheckResult {
+		return validations.CheckResult{Failure: validations.Failure{
+			Check: check, Kind: kind, Name: "app", Namespace: "ns",
+			Container: container, ContainerPath: path,
+		}}
+	}
+
+	t.Run("the patch fixes the container of the object", func(t *testing.T) {
+		// Given
+		deployment := appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "sidecar"},
+				{Name: "app", Image: "app"},
+			}},
+		}}}
+		original, err := json.Marshal(deployment)
+		assert.NoError(t, err)
+
+		// When
+		suggestions := Suggest([]validations.CheckResult{
+			failure("run-as-non-root", "Deployment", "app", "containers[1]"),
+		})
+
+		// Assert
+		assert.Len(t, suggestions, 1)
+		assert.Equal(t, StrategicMergePatchType, suggestions[0].PatchType)
+		assert.Contains(t, suggestions[0].Command, "kubectl patch deployment app -n ns --type strategic -p '")
+
+		patched, err := strategicpatch.StrategicMergePatch(original, suggestions[0].Patch, appsv1.Deployment{})
+		assert.NoError(t, err)
+		var result appsv1.Deployment
+		assert.NoError(t, json.Unmarshal(patched, &result))
+		containers := result.Spec.Template.Spec.Containers
+		assert.Len(t, containers, 2)
+		assert.Nil(t, containers[0].SecurityContext)
+		assert.Equal(t, "app", containers[1].Image)
+		assert.True(t, *containers[1].SecurityContext.RunAsNonRoot)
+	})
+
+	t.Run("init containers are patched in the pod spec of the kind", func(t *testing.T) {
+		// When
+		suggestions := Suggest([]validations.CheckResult{
+			failure("unset-cpu-requirements", "CronJob", "init", "initContainers[0]"),
+		})
+
+		// Assert
+		assert.Len(t, suggestions, 1)
+		assert.JSONEq(t, `{"spec": {"jobTemplate": {"spec": {"template": {"spec": {"initContainers": [
+			{"name": "init", "resources": {"requests": {"cpu": "100m"}}}
+		]}}}}}}`, string(suggestions[0].Patch))
+	})
+
+	t.Run("unsupported failures are skipped", func(t *testing.T) {
+		// When
+		suggestions := Suggest([]validations.CheckResult{
+			failure("minimum-three-replicas", "Deployment", "", ""),
+			failure("run-as-non-root", "Deployment", "", ""),
+			failure("run-as-non-root", "Unknown", "app", "containers[0]"),
+			failure("run-as-non-root", "Pod", "app", "containers[0]"),
+		})
+
+		// Assert
+		assert.Empty(t, suggestions)
+	})
+}
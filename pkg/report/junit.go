@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/app-sre/deployment-validation-operator/config"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string       `xml:"classname,attr"`
+	Name      string       `xml:"name,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// renderJUnit writes the report as JUnit XML, with a test suite per namespace
+// and a failed test case per object and check. As only the failures are
+// recorded, the checks passing are not listed.
+func (r Report) renderJUnit(w io.Writer) error {
+	suites := junitTestSuites{Name: config.OperatorName, Suites: []junitTestSuite{}}
+	timestamp := r.GeneratedAt.Format("2006-01-02T15:04:05")
+
+	for _, ns := range r.Namespaces {
+		suite := junitTestSuite{Name: ns.Name, Timestamp: timestamp}
+		for _, check := range ns.Checks {
+			for _, f := range check.Failures {
+				name := check.Name
+				if f.ContainerPath != "" {
+					name = fmt.Sprintf("%s[%s]", check.Name, f.ContainerPath)
+				}
+
+				suite.Cases = append(suite.Cases, junitTestCase{
+					ClassName: strings.Join([]string{ns.Name, f.Kind, f.Name}, "."),
+					Name:      name,
+					Failure: junitFailure{
+						Message: f.Message,
+						Type:    check.Name,
+						Text:    junitFailureText(check, f),
+					},
+				})
+			}
+		}
+		suite.Tests = len(suite.Cases)
+		suite.Failures = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitFailureText(check Check, f validations.CheckResult) string {
+	var lines []string
+	if since := formatSince(f.FailingSince); since != "" {
+		lines = append(lines, "Failing since: "+since)
+	}
+	if team := formatTeam(f.Team, f.Contact); team != "" {
+		lines = append(lines, "Team: "+team)
+	}
+	if check.Description != "" {
+		lines = append(lines, "Description: "+check.Description)
+	}
+	if check.Remediation != "" {
+		lines = append(lines, "Remediation: "+check.Remediation)
+	}
+	lines = append(lines, "Documentation: "+check.DocURL)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+// manifestExtensions are the extensions of the files loaded from directories
+var manifestExtensions = map[string]struct{}{".yaml": {}, ".yml": {}, ".json": {}}
+
+// LoadManifests returns the objects defined in the given files, and in the YAML
+// and JSON files of the given directories, so that they can be validated without
+// a cluster. The objects of kinds unknown to the scheme are skipped.
+func LoadManifests(scheme *runtime.Scheme, paths []string) ([]client.Object, error) {
+	var objects []client.Object
+
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// files given explicitly are loaded whatever their extension
+			if _, ok := manifestExtensions[strings.ToLower(filepath.Ext(file))]; !ok && file != path {
+				return nil
+			}
+
+			fileObjects, err := loadManifestFile(scheme, file)
+			if err != nil {
+				return fmt.Errorf("loading %s: %w", file, err)
+			}
+			objects = append(objects, fileObjects...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// ManifestGroup is the objects of a namespace, empty for the cluster-scoped objects
+type ManifestGroup struct {
+	Namespace string
+	Objects   []client.Object
+}
+
+// GroupByNamespace returns the given objects grouped by namespace, in the order
+// the namespaces are first seen
+func GroupByNamespace(objects []client.Object) []ManifestGroup {
+	var groups []ManifestGroup
+	index := map[string]int{}
+	for _, obj := range objects {
+		i, ok := index[obj.GetNamespace()]
+		if !ok {
+			i = len(groups)
+			index[obj.GetNamespace()] = i
+			groups = append(groups, ManifestGroup{Namespace: obj.GetNamespace()})
+		}
+		groups[i].Objects = append(groups[i].Objects, obj)
+	}
+	return groups
+}
+
+// ManifestNamespaces returns the metadata of the Namespaces defined by the given objects,
+// by name, so that the objects can be attributed to teams from their namespace
+func ManifestNamespaces(objects []client.Object) map[string]validations.Namespace {
+	namespaces := map[string]validations.Namespace{}
+	for _, obj := range objects {
+		if ns, ok := obj.(*corev1.Namespace); ok {
+			namespaces[ns.Name] = validations.Namespace{
+				UID: string(ns.UID), Labels: ns.Labels, Annotations: ns.Annotations,
+			}
+		}
+	}
+	return namespaces
+}
+
+func loadManifestFile(scheme *runtime.Scheme, file string) ([]client.Object, error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objects []client.Object
+	decoder := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("decoding manifest: %w", err)
+		}
+		// empty documents
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		items := []unstructured.Unstructured{*u}
+		if u.IsList() {
+			list, err := u.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("decoding list: %w", err)
+			}
+			items = list.Items
+		}
+
+		for i := range items {
+			obj, ok, err := toTyped(scheme, &items[i])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				objects = append(objects, obj)
+			}
+		}
+	}
+}
+
+// toTyped converts the unstructured object into the typed object of its kind,
+// returning false if the kind is unknown to the scheme
+func toTyped(scheme *runtime.Scheme, u *unstructured.Unstructured) (client.Object, bool, error) {
+	gvk := u.GroupVersionKind()
+	if !scheme.Recognizes(gvk) {
+		return nil, false, nil
+	}
+
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return nil, false, fmt.Errorf("creating new object of type %s: %w", gvk, err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+		return nil, false, fmt.Errorf("converting %s %s: %w", gvk.Kind, u.GetName(), err)
+	}
+
+	obj, ok := typed.(client.Object)
+	return obj, ok, nil
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+)
+
+const testManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: ns
+spec:
+  replicas: 1
+---
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: custom
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: svc
+    namespace: ns
+`
+
+func TestLoadManifests(t *testing.T) {
+	// Given
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(testManifests), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o600))
+
+	// When
+	objects, err := LoadManifests(scheme, []string{dir})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "Deployment", objects[0].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "app", objects[0].GetName())
+	assert.Equal(t, "Service", objects[1].GetObjectKind().GroupVersionKind().Kind)
+	assert.Equal(t, "ns", objects[1].GetNamespace())
+}
+
+func TestGroupByNamespace(t *testing.T) {
+	// Given
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "ns-b", UID: "ns-b-uid", Annotations: map[string]string{"example.com/owner-team": "payments"},
+	}}
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns-b"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns-a"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "ns-b"}},
+		namespace,
+	}
+
+	// When
+	groups := GroupByNamespace(objects)
+	namespaces := ManifestNamespaces(objects)
+
+	// Assert
+	assert.Equal(t, []ManifestGroup{
+		{Namespace: "ns-b", Objects: []client.Object{objects[0], objects[2]}},
+		{Namespace: "ns-a", Objects: []client.Object{objects[1]}},
+		{Namespace: "", Objects: []client.Object{namespace}},
+	}, groups)
+	assert.Equal(t, map[string]validations.Namespace{
+		"ns-b": {UID: "ns-b-uid", Annotations: map[string]string{"example.com/owner-team": "payments"}},
+	}, namespaces)
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"encoding/csv"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var funcs = map[string]interface{}{
+	"since": formatSince,
+	"team":  formatTeam,
+	"cell": func(s string) string {
+		// keep the markdown table on a single line per row
+		return strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ").Replace(s)
+	},
+}
+
+// formatSince formats the time a check has been failing since,
+// unknown when the results come from a single evaluation
+func formatSince(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatTeam formats the team owning the object along with its contact, if any
+func formatTeam(team, contact string) string {
+	if team == "" || contact == "" {
+		return team
+	}
+	return team + " (" + contact + ")"
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(
+	`# Deployment Validation Operator compliance report
+
+Generated at {{ since .GeneratedAt }}: {{ .Failures }} failing check(s).
+{{ range .Namespaces }}
+## Namespace {{ .Name }}
+{{ range .Checks }}
+### {{ .Name }}
+
+{{ with .Description }}{{ . }}
+
+{{ end }}{{ with .Remediation }}**Remediation:** {{ . }}
+
+{{ end }}**Documentation:** {{ .DocURL }}
+
+| Kind | Name | Message | Team | Failing since |
+| --- | --- | --- | --- | --- |
+{{ range .Failures }}| {{ cell .Kind }} | {{ cell .Name }} | {{ cell .Message }} | ` +
+		`{{ cell (team .Team .Contact) }} | {{ since .FailingSince }} |
+{{ end }}{{ end }}{{ end }}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Deployment Validation Operator compliance report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>Deployment Validation Operator compliance report</h1>
+<p>Generated at {{ since .GeneratedAt }}: {{ .Failures }} failing check(s).</p>
+{{ range .Namespaces }}
+<h2>Namespace {{ .Name }}</h2>
+{{ range .Checks }}
+<h3>{{ .Name }}</h3>
+{{ with .Description }}<p>{{ . }}</p>{{ end }}
+{{ with .Remediation }}<p><strong>Remediation:</strong> {{ . }}</p>{{ end }}
+<p><strong>Documentation:</strong> <a href="{{ .DocURL }}">{{ .DocURL }}</a></p>
+<table>
+<tr><th>Kind</th><th>Name</th><th>Message</th><th>Team</th><th>Failing since</th></tr>
+{{ range .Failures }}<tr><td>{{ .Kind }}</td><td>{{ .Name }}</td><td>{{ .Message }}</td>
+<td>{{ team .Team .Contact }}</td><td>{{ since .FailingSince }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ end }}
+</body>
+</html>
+`))
+
+// renderCSV writes a row per failure, along with the description of the check
+func (r Report) renderCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"namespace", "check", "kind", "name", "uid", "message",
+		"failing_since", "description", "remediation", "documentation", "team", "contact",
+	}); err != nil {
+		return err
+	}
+
+	for _, ns := range r.Namespaces {
+		for _, check := range ns.Checks {
+			for _, f := range check.Failures {
+				if err := cw.Write([]string{
+					ns.Name, check.Name, f.Kind, f.Name, f.UID, f.Message,
+					formatSince(f.FailingSince), check.Description, check.Remediation, check.DocURL,
+					f.Team, f.Contact,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
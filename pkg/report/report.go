@@ -0,0 +1,143 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"golang.stackrox.io/kube-linter/pkg/config"
+)
+
+// Report formats
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+	FormatSARIF    = "sarif"
+	FormatJUnit    = "junit"
+)
+
+var contentTypes = map[string]string{
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatCSV:      "text/csv; charset=utf-8",
+	FormatSARIF:    "application/sarif+json",
+	FormatJUnit:    "application/xml",
+}
+
+// Report lists the failing checks grouped by namespace and check
+type Report struct {
+	GeneratedAt time.Time
+	Namespaces  []Namespace
+	// Failures is the total number of failures
+	Failures int
+}
+
+// Namespace groups the failing checks of a namespace
+type Namespace struct {
+	Name   string
+	Checks []Check
+}
+
+// Check groups the objects failing a check in a namespace
+type Check struct {
+	Name        string
+	Description string
+	Remediation string
+	DocURL      string
+	Failures    []validations.CheckResult
+}
+
+// CheckLookup returns the specification of the given check
+type CheckLookup func(name string) (config.Check, bool)
+
+// New returns the report of the given results, the checks being
+// described by the specification returned by the lookup function
+func New(results []validations.CheckResult, lookup CheckLookup) Report {
+	report := Report{GeneratedAt: time.Now().UTC(), Failures: len(results)}
+
+	byNamespace := map[string]map[string][]validations.CheckResult{}
+	for _, result := range results {
+		if _, ok := byNamespace[result.Namespace]; !ok {
+			byNamespace[result.Namespace] = map[string][]validations.CheckResult{}
+		}
+		checks := byNamespace[result.Namespace]
+		checks[result.Check] = append(checks[result.Check], result)
+	}
+
+	for _, namespace := range sortedKeys(byNamespace) {
+		ns := Namespace{Name: namespace}
+		for _, name := range sortedKeys(byNamespace[namespace]) {
+			check := Check{Name: name, DocURL: validations.CheckDocURL(name)}
+			if spec, ok := lookup(name); ok {
+				check.Description = spec.Description
+				check.Remediation = spec.Remediation
+			}
+
+			check.Failures = byNamespace[namespace][name]
+			sort.SliceStable(check.Failures, func(i, j int) bool {
+				if check.Failures[i].Kind != check.Failures[j].Kind {
+					return check.Failures[i].Kind < check.Failures[j].Kind
+				}
+				return check.Failures[i].Name < check.Failures[j].Name
+			})
+			ns.Checks = append(ns.Checks, check)
+		}
+		report.Namespaces = append(report.Namespaces, ns)
+	}
+
+	return report
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Render writes the report in the given format
+func (r Report) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatHTML:
+		return htmlTemplate.Execute(w, r)
+	case FormatMarkdown:
+		return markdownTemplate.Execute(w, r)
+	case FormatCSV:
+		return r.renderCSV(w)
+	case FormatSARIF:
+		return r.renderSARIF(w)
+	case FormatJUnit:
+		return r.renderJUnit(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// Handler serves the report of the checks currently failing. The format
+// query parameter selects the format, HTML being served by default.
+func Handler(engine validations.Interface) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatHTML
+		}
+		contentType, ok := contentTypes[format]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown report format %q", format), http.StatusBadRequest)
+			return
+		}
+
+		report := New(engine.GetResults(), engine.GetCheck)
+
+		w.Header().Set("Content-Type", contentType)
+		if err := report.Render(w, format); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations"
+	"github.com/stretchr/testify/assert"
+	"golang.stackrox.io/kube-linter/pkg/config"
+)
+
+func newTestReport() Report {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := func(namespace, name, check, message string) validations.CheckResult {
+		return validations.CheckResult{
+			Failure: validations.Failure{
+				Check: check, Kind: "Deployment", Name: name, Namespace: namespace,
+				UID: name, Message: message, Team: "payments", Contact: "#payments",
+			},
+			FailingSince: since,
+		}
+	}
+	lookup := func(name string) (config.Check, bool) {
+		if name != "minimum-three-replicas" {
+			return config.Check{}, false
+		}
+		return config.Check{
+			Description: "Indicates when a deployment uses less than three replicas",
+			Remediation: "Increase the number of replicas",
+		}, true
+	}
+
+	return New([]validations.CheckResult{
+		result("ns-b", "app", "minimum-three-replicas", "object has 1 replica"),
+		result("ns-a", "web", "minimum-three-replicas", "object has 1 replica"),
+		result("ns-a", "app", "minimum-three-replicas", "object has 2 replicas"),
+		result("ns-a", "app", "custom-check", "a | b"),
+	}, lookup)
+}
+
+func TestNew(t *testing.T) {
+	// When
+	report := newTestReport()
+
+	// Assert
+	assert.Equal(t, 4, report.Failures)
+	assert.Len(t, report.Namespaces, 2)
+	ns := report.Namespaces[0]
+	assert.Equal(t, "ns-a", ns.Name)
+	assert.Equal(t, "custom-check", ns.Checks[0].Name)
+	assert.Empty(t, ns.Checks[0].Description)
+	assert.Equal(t, validations.CheckDocURL("custom-check"), ns.Checks[0].DocURL)
+	replicas := ns.Checks[1]
+	assert.Equal(t, "Increase the number of replicas", replicas.Remediation)
+	assert.Equal(t, "app", replicas.Failures[0].Name)
+	assert.Equal(t, "web", replicas.Failures[1].Name)
+}
+
+func TestRender(t *testing.T) {
+	report := newTestReport()
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, report.Render(&buf, FormatMarkdown))
+
+		assert.Contains(t, buf.String(), "## Namespace ns-a")
+		assert.Contains(t, buf.String(), "**Remediation:** Increase the number of replicas")
+		assert.Contains(t, buf.String(),
+			`| Deployment | app | a \| b | payments (#payments) | 2024-01-01T00:00:00Z |`)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, report.Render(&buf, FormatHTML))
+
+		assert.Contains(t, buf.String(), "<h2>Namespace ns-b</h2>")
+		assert.Contains(t, buf.String(), `<a href="`+validations.CheckDocURL("minimum-three-replicas")+`">`)
+		assert.Contains(t, buf.String(), "<td>payments (#payments)</td>")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, report.Render(&buf, FormatCSV))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, records, 5)
+		assert.Equal(t, []string{"ns-a", "custom-check", "Deployment", "app", "app", "a | b",
+			"2024-01-01T00:00:00Z", "", "", validations.CheckDocURL("custom-check"),
+			"payments", "#payments"}, records[1])
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		assert.Error(t, report.Render(&bytes.Buffer{}, "pdf"))
+	})
+}
+
+func TestRenderSARIF(t *testing.T) {
+	// Given
+	report := newTestReport()
+	report.Namespaces[1].Checks[0].Failures[0].Container = "app"
+	report.Namespaces[1].Checks[0].Failures[0].ContainerPath = "containers[1]"
+
+	// When
+	var buf bytes.Buffer
+	assert.NoError(t, report.Render(&buf, FormatSARIF))
+
+	// Assert
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	run := log.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "Increase the number of replicas", run.Tool.Driver.Rules[1].Help.Text)
+	assert.Len(t, run.Results, 4)
+	last := run.Results[3]
+	assert.Equal(t, "minimum-three-replicas", last.RuleID)
+	assert.Equal(t, 1, last.RuleIndex)
+	assert.Equal(t, []sarifLogicalLocation{
+		{Name: "app", FullyQualifiedName: "ns-b/Deployment/app", Kind: "object"},
+		{Name: "app", FullyQualifiedName: "ns-b/Deployment/app/containers[1]", Kind: "member"},
+	}, last.Locations[0].LogicalLocations)
+	assert.Equal(t, "2024-01-01T00:00:00Z", last.Properties["failingSince"])
+	assert.Equal(t, "payments", last.Properties["team"])
+	assert.Equal(t, "#payments", last.Properties["contact"])
+}
+
+func TestRenderJUnit(t *testing.T) {
+	// Given
+	report := newTestReport()
+	report.Namespaces[1].Checks[0].Failures[0].ContainerPath = "containers[1]"
+
+	// When
+	var buf bytes.Buffer
+	assert.NoError(t, report.Render(&buf, FormatJUnit))
+
+	// Assert
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 4, suites.Failures)
+	assert.Len(t, suites.Suites, 2)
+	assert.Equal(t, "ns-a", suites.Suites[0].Name)
+	assert.Equal(t, 3, suites.Suites[0].Tests)
+	testCase := suites.Suites[1].Cases[0]
+	assert.Equal(t, "ns-b.Deployment.app", testCase.ClassName)
+	assert.Equal(t, "minimum-three-replicas[containers[1]]", testCase.Name)
+	assert.Equal(t, "object has 1 replica", testCase.Failure.Message)
+	assert.Contains(t, testCase.Failure.Text, "Remediation: Increase the number of replicas")
+	assert.Contains(t, testCase.Failure.Text, "Team: payments (#payments)")
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/app-sre/deployment-validation-operator/config"
+	"github.com/app-sre/deployment-validation-operator/version"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	projectURL   = "https://github.com/app-sre/deployment-validation-operator"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription *sarifText   `json:"shortDescription,omitempty"`
+	Help             *sarifText   `json:"help,omitempty"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+	DefaultConfig    sarifRuleCfg `json:"defaultConfiguration"`
+}
+
+type sarifRuleCfg struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    sarifText         `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// renderSARIF writes the report as a SARIF 2.1.0 log, each check being a rule. The objects,
+// and the containers when known, are located by logical locations such as
+// <namespace>/<kind>/<name>/containers[0], as there is no source file in the cluster.
+func (r Report) renderSARIF(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           config.OperatorName,
+			Version:        version.Version,
+			InformationURI: projectURL,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := map[string]int{}
+	for _, ns := range r.Namespaces {
+		for _, check := range ns.Checks {
+			if _, ok := ruleIndex[check.Name]; !ok {
+				ruleIndex[check.Name] = len(run.Tool.Driver.Rules)
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(check))
+			}
+
+			for _, f := range check.Failures {
+				objectName := strings.Join([]string{ns.Name, f.Kind, f.Name}, "/")
+				locations := []sarifLogicalLocation{
+					{Name: f.Name, FullyQualifiedName: objectName, Kind: "object"},
+				}
+				if f.ContainerPath != "" {
+					locations = append(locations, sarifLogicalLocation{
+						Name:               f.Container,
+						FullyQualifiedName: objectName + "/" + f.ContainerPath,
+						Kind:               "member",
+					})
+				}
+
+				properties := map[string]string{"namespace": ns.Name, "kind": f.Kind, "uid": f.UID}
+				if f.Container != "" {
+					properties["container"] = f.Container
+				}
+				if f.Team != "" {
+					properties["team"] = f.Team
+				}
+				if f.Contact != "" {
+					properties["contact"] = f.Contact
+				}
+				if since := formatSince(f.FailingSince); since != "" {
+					properties["failingSince"] = since
+				}
+
+				run.Results = append(run.Results, sarifResult{
+					RuleID:     check.Name,
+					RuleIndex:  ruleIndex[check.Name],
+					Level:      "warning",
+					Message:    sarifText{Text: f.Message},
+					Locations:  []sarifLocation{{LogicalLocations: locations}},
+					Properties: properties,
+				})
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
+
+func newSARIFRule(check Check) sarifRule {
+	rule := sarifRule{ID: check.Name, HelpURI: check.DocURL, DefaultConfig: sarifRuleCfg{Level: "warning"}}
+	if check.Description != "" {
+		rule.ShortDescription = &sarifText{Text: check.Description}
+	}
+	if check.Remediation != "" {
+		rule.Help = &sarifText{Text: check.Remediation}
+	}
+	return rule
+}
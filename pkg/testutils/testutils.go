@@ -2,6 +2,8 @@ package testutils
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"path"
@@ -13,8 +15,14 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/mcuadros/go-defaults"
 	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// helmReleaseSecretType is the type of the Secrets Helm 3 stores the releases in,
+// as helm.ReleaseSecretType which cannot be imported by the tests of the helm package
+const helmReleaseSecretType core_v1.SecretType = "helm.sh/release.v1"
+
 type TemplateArgs struct {
 	Replicas         int  `default:"3"`
 	ResourceLimits   bool `default:"true"`
@@ -89,3 +97,30 @@ func ObjectKind(obj interface{}) string {
 	kind := reflect.TypeOf(obj).String()
 	return strings.SplitN(kind, ".", 2)[1]
 }
+
+// NewHelmReleaseSecret returns the Secret of a deployed Helm release holding the given
+// release, base64 encoded and, when compress is true, gzipped as Helm stores it
+func NewHelmReleaseSecret(namespace, name, release string, compress bool) (*core_v1.Secret, error) {
+	data := []byte(release)
+	if compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+
+	return &core_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"owner": "helm", "status": "deployed"},
+		},
+		Type: helmReleaseSecretType,
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(data))},
+	}, nil
+}
@@ -39,6 +39,11 @@ func IsOwner(obj client.Object) bool {
 	return true
 }
 
+// IsDeploymentLike returns true if the given kind is a deployment-like resource
+func IsDeploymentLike(gvk schema.GroupVersionKind) bool {
+	return deploymentLikeMatcher.Matches(gvk)
+}
+
 // IsOpenshift identify environment and returns true if its openshift else false
 func IsOpenshift(osKind map[string]bool) (bool, error) {
 	log.Info("Checking User Environment in IsOpenshift.")
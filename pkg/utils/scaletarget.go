@@ -0,0 +1,59 @@
+package utils
+
+import (
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KEDAScaledObject is the group and kind of the KEDA ScaledObjects
+var KEDAScaledObject = schema.GroupKind{Group: "keda.sh", Kind: "ScaledObject"}
+
+var horizontalPodAutoscaler = schema.GroupKind{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}
+
+// ScaleTarget identifies the workload scaled by an autoscaler
+type ScaleTarget struct {
+	Kind string
+	Name string
+}
+
+// GetScaleTarget returns the workload scaled, through its spec.scaleTargetRef, by the given
+// HorizontalPodAutoscaler or KEDA ScaledObject, typed or unstructured.
+// The returned boolean is false for the other objects.
+func GetScaleTarget(obj runtime.Object) (ScaleTarget, bool) {
+	switch o := obj.(type) {
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		return ScaleTarget{Kind: o.Spec.ScaleTargetRef.Kind, Name: o.Spec.ScaleTargetRef.Name}, true
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		return ScaleTarget{Kind: o.Spec.ScaleTargetRef.Kind, Name: o.Spec.ScaleTargetRef.Name}, true
+	case *unstructured.Unstructured:
+		gk := o.GroupVersionKind().GroupKind()
+		if gk != KEDAScaledObject && gk != horizontalPodAutoscaler {
+			return ScaleTarget{}, false
+		}
+		kind, _, _ := unstructured.NestedString(o.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(o.Object, "spec", "scaleTargetRef", "name")
+		if kind == "" && gk == KEDAScaledObject {
+			// the ScaledObjects scale a Deployment unless set otherwise
+			kind = "Deployment"
+		}
+		return ScaleTarget{Kind: kind, Name: name}, name != ""
+	default:
+		return ScaleTarget{}, false
+	}
+}
+
+// IsScaleTargetOf returns true if the given object is the workload scaled by the autoscaler
+func IsScaleTargetOf(obj client.Object, autoscaler runtime.Object) bool {
+	target, ok := GetScaleTarget(autoscaler)
+	return ok && target.Name == obj.GetName() && target.Kind == obj.GetObjectKind().GroupVersionKind().Kind
+}
+
+// IsHorizontalPodAutoscaler returns true if the given object, typed or unstructured,
+// is a HorizontalPodAutoscaler
+func IsHorizontalPodAutoscaler(obj runtime.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind().GroupKind() == horizontalPodAutoscaler
+}
@@ -1,7 +1,7 @@
 package all
 
-/*
 import (
 	// Import all check templates.
+	_ "github.com/app-sre/deployment-validation-operator/pkg/validations/templates/hpa"   // nolint:golint
+	_ "github.com/app-sre/deployment-validation-operator/pkg/validations/templates/image" // nolint:golint
 )
-*/
@@ -25,14 +25,28 @@ type Request struct {
 	Namespace    string
 	NamespaceUID string
 	UID          string
+	// Labels holds the values of the object and namespace metadata
+	// promoted to metric labels, see MetricLabelsConfig
+	Labels map[string]string
+}
+
+// Namespace holds the metadata of the namespace of a group of validated objects
+type Namespace struct {
+	UID         string
+	Labels      map[string]string
+	Annotations map[string]string
 }
 
 func (r *Request) ToPromLabels() prometheus.Labels {
-	return prometheus.Labels{
+	labels := prometheus.Labels{
 		"kind":          r.Kind,
 		"name":          r.Name,
 		"namespace":     r.Namespace,
 		"namespace_uid": r.NamespaceUID,
 		"uid":           r.UID,
 	}
+	for name, value := range r.Labels {
+		labels[name] = value
+	}
+	return labels
 }
@@ -0,0 +1,88 @@
+package validations
+
+import (
+	klConfig "golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations/templates/hpa"
+	"github.com/app-sre/deployment-validation-operator/pkg/validations/templates/image"
+)
+
+// hpaChecks are the checks of the HorizontalPodAutoscalers
+var hpaChecks = []klConfig.Check{
+	{
+		Name:        "conflicting-hpas",
+		Description: "Indicates when a workload is scaled by several HorizontalPodAutoscalers",
+		Remediation: "Scale each workload with a single HorizontalPodAutoscaler, " +
+			"or a single KEDA ScaledObject, as they otherwise compete for its replicas.",
+		Template: hpa.ConflictingTemplateKey,
+		Scope:    &klConfig.ObjectKindsDesc{ObjectKinds: []string{objectkinds.HorizontalPodAutoscaler}},
+	},
+	{
+		Name: "hpa-target-without-requests",
+		Description: "Indicates when a HorizontalPodAutoscaler scales on the utilization of a resource " +
+			"not requested by the containers of its workload",
+		Remediation: "Set the requests of the resources the HorizontalPodAutoscaler scales on, " +
+			"as their utilization is relative to the requests.",
+		Template: hpa.ResourceRequestsTemplateKey,
+		Scope:    &klConfig.ObjectKindsDesc{ObjectKinds: []string{objectkinds.HorizontalPodAutoscaler}},
+	},
+}
+
+// imageChecks returns the checks of the container images, parameterized by the given configuration
+func imageChecks(images ImagesConfig) []klConfig.Check {
+	scope := &klConfig.ObjectKindsDesc{ObjectKinds: []string{objectkinds.DeploymentLike}}
+	return []klConfig.Check{
+		{
+			Name: "disallowed-image-registry",
+			Description: "Indicates when a container image is pulled from a denied registry, " +
+				"or from a registry not allowed",
+			Remediation: "Pull the image from one of the allowed registries, mirroring it there if needed.",
+			Template:    image.RegistriesTemplateKey,
+			Params:      images.registriesParams(),
+			Scope:       scope,
+		},
+		{
+			Name:        "image-without-digest",
+			Description: "Indicates when a container image is not pinned by digest",
+			Remediation: "Reference the image by digest, e.g. registry/repository@sha256:<digest>, " +
+				"so every replica runs the same image.",
+			Template: image.DigestTemplateKey,
+			Params:   images.digestParams(),
+			Scope:    scope,
+		},
+		{
+			Name:        "mutable-image-tag",
+			Description: "Indicates when a container image is referenced by a mutable tag, such as latest",
+			Remediation: "Reference the image by an immutable version tag, or by digest.",
+			Template:    image.MutableTagTemplateKey,
+			Params:      images.tagsParams(),
+			Scope:       scope,
+		},
+		{
+			Name: "image-pull-policy-mismatch",
+			Description: "Indicates when a container image referenced by a mutable tag is not pulled " +
+				"every time the container starts",
+			Remediation: "Set the imagePullPolicy to Always, or reference the image by an immutable tag.",
+			Template:    image.PullPolicyTemplateKey,
+			Params:      images.tagsParams(),
+			Scope:       scope,
+		},
+	}
+}
+
+// dvoChecks returns the checks built on the DVO templates, registered along with the
+// kube-linter built-in ones. As them, they must be included to be enabled.
+func dvoChecks(images ImagesConfig) []klConfig.Check {
+	return append(append([]klConfig.Check(nil), hpaChecks...), imageChecks(images)...)
+}
+
+// dvoCheckNames returns the names of the checks built on the DVO templates
+func dvoCheckNames() []string {
+	checks := dvoChecks(ImagesConfig{})
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
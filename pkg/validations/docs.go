@@ -0,0 +1,39 @@
+package validations
+
+const (
+	// kubeLinterDocsURL documents the kube-linter built-in checks
+	kubeLinterDocsURL = "https://docs.kubelinter.io/#/generated/checks"
+	haApplicationsURL = "https://cloud.redhat.com/blog/" +
+		"deploying-highly-available-applications-openshift-kubernetes"
+	ocpSchedulingDocsURL = "https://docs.openshift.com/container-platform/4.10/nodes/scheduling/"
+	// dvoChecksDocsURL documents the checks built on the DVO templates
+	dvoChecksDocsURL = "https://github.com/app-sre/deployment-validation-operator/blob/master/docs/checks.md"
+)
+
+// checkDocURLs are the links documenting the best practices behind some of the
+// enabled checks, and the checks built on the DVO templates. They must be kept
+// in sync with docs/checks.md.
+var checkDocURLs = map[string]string{
+	"conflicting-hpas":            dvoChecksDocsURL + "#conflicting-hpas",
+	"hpa-target-without-requests": dvoChecksDocsURL + "#hpa-target-without-requests",
+	"disallowed-image-registry":   dvoChecksDocsURL + "#disallowed-image-registry",
+	"image-without-digest":        dvoChecksDocsURL + "#image-without-digest",
+	"mutable-image-tag":           dvoChecksDocsURL + "#mutable-image-tag",
+	"image-pull-policy-mismatch":  dvoChecksDocsURL + "#image-pull-policy-mismatch",
+
+	"minimum-three-replicas": haApplicationsURL,
+	"no-anti-affinity":       ocpSchedulingDocsURL + "nodes-scheduler-pod-affinity.html",
+	"no-node-affinity": ocpSchedulingDocsURL +
+		"nodes-scheduler-node-affinity.html#nodes-scheduler-node-affinity-about_nodes-scheduler-node-affinity",
+	"unset-cpu-requirements":    haApplicationsURL,
+	"unset-memory-requirements": haApplicationsURL,
+}
+
+// CheckDocURL returns the link documenting the given check, defaulting
+// to the kube-linter documentation of the built-in checks
+func CheckDocURL(check string) string {
+	if url, ok := checkDocURLs[check]; ok {
+		return url
+	}
+	return kubeLinterDocsURL + "?id=" + check
+}
@@ -0,0 +1,26 @@
+package validations
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDocURL(t *testing.T) {
+	// Given
+	docs, err := os.ReadFile("../../docs/checks.md")
+	assert.NoError(t, err)
+
+	for _, check := range dvoCheckNames() {
+		// When
+		url := CheckDocURL(check)
+
+		// Assert
+		assert.Equal(t, dvoChecksDocsURL+"#"+check, url)
+		assert.True(t, strings.Contains(string(docs), "\n#### "+check+"\n"),
+			"the check %s is documented in docs/checks.md", check)
+	}
+	assert.Equal(t, kubeLinterDocsURL+"?id=host-pid", CheckDocURL("host-pid"))
+}
@@ -0,0 +1,235 @@
+package validations
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const failingSinceMetricName = "dvo_check_failing_since_seconds"
+
+// CheckResult is a check currently failing for an object, along with the time
+// it was first seen failing
+type CheckResult struct {
+	Failure
+	FailingSince time.Time `json:"failingSince"`
+}
+
+type failingCheck struct {
+	since time.Time
+	// failures reported by the check, e.g. one per container
+	failures []Failure
+	labels   prometheus.Labels
+}
+
+// FailureTracker records, for each object and check, the time the check was first
+// seen failing. The time is kept as long as the check keeps failing, and exported
+// as a Unix timestamp by the dvo_check_failing_since_seconds metric.
+// The records are held in memory, and can be seeded with the times recorded
+// before the operator restarted.
+type FailureTracker struct {
+	mux sync.RWMutex
+	// failing checks, by object UID and check name
+	failing map[string]map[string]failingCheck
+	// seeds are the times the checks were first seen failing before the restart,
+	// by object UID and check name, until the object is validated again
+	seeds map[string]map[string]time.Time
+	since *prometheus.GaugeVec
+	now   func() time.Time
+}
+
+// NewFailureTracker returns a FailureTracker whose metric is labelled
+// as the check metrics with the given configuration
+func NewFailureTracker(labels MetricLabelsConfig) *FailureTracker {
+	return &FailureTracker{
+		failing: map[string]map[string]failingCheck{},
+		seeds:   map[string]map[string]time.Time{},
+		since: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: failingSinceMetricName,
+				Help: "Unix timestamp of the first time a check was seen failing for an object.",
+			}, append(MetricLabelNames(labels), "check")),
+		now: time.Now,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (t *FailureTracker) Describe(ch chan<- *prometheus.Desc) {
+	t.since.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (t *FailureTracker) Collect(ch chan<- prometheus.Metric) {
+	t.since.Collect(ch)
+}
+
+// Seed sets the times the checks were first seen failing before the operator restarted,
+// by object UID and check name. A seeded time is kept if the check is still failing
+// the next time the object is validated, and forgotten otherwise.
+func (t *FailureTracker) Seed(times map[string]map[string]time.Time) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.seeds = times
+}
+
+// set records the failures of the check for the object identified by the request,
+// keeping the time it was first seen failing if it was already failing
+func (t *FailureTracker) set(req Request, check string, failures []Failure) {
+	if t == nil {
+		return
+	}
+
+	labels := req.ToPromLabels()
+	labels["check"] = check
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	since := t.now().UTC()
+	if previous, ok := t.failing[req.UID][check]; ok {
+		since = previous.since
+		// the promoted metadata may have changed since the previous run
+		t.since.Delete(previous.labels)
+	} else if seed, ok := t.seeds[req.UID][check]; ok {
+		since = seed
+	}
+
+	if _, ok := t.failing[req.UID]; !ok {
+		t.failing[req.UID] = map[string]failingCheck{}
+	}
+	t.failing[req.UID][check] = failingCheck{since: since, failures: failures, labels: labels}
+	t.since.With(labels).Set(float64(since.Unix()))
+}
+
+// retain forgets the checks of the object with the given UID
+// which are not part of the given failing checks
+func (t *FailureTracker) retain(uid string, failing map[string]struct{}) {
+	if t == nil {
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	delete(t.seeds, uid)
+	for check, f := range t.failing[uid] {
+		if _, ok := failing[check]; !ok {
+			t.since.Delete(f.labels)
+			delete(t.failing[uid], check)
+		}
+	}
+	if len(t.failing[uid]) == 0 {
+		delete(t.failing, uid)
+	}
+}
+
+// deleteObject forgets the checks of the object with the given UID
+func (t *FailureTracker) deleteObject(uid string) {
+	t.retain(uid, nil)
+}
+
+// deleteChecks forgets the given checks for every object
+func (t *FailureTracker) deleteChecks(checks []string) {
+	if t == nil || len(checks) == 0 {
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for uid, failing := range t.failing {
+		for _, check := range checks {
+			if f, ok := failing[check]; ok {
+				t.since.Delete(f.labels)
+				delete(failing, check)
+			}
+		}
+		if len(failing) == 0 {
+			delete(t.failing, uid)
+		}
+	}
+	for uid, seeds := range t.seeds {
+		for _, check := range checks {
+			delete(seeds, check)
+		}
+		if len(seeds) == 0 {
+			delete(t.seeds, uid)
+		}
+	}
+}
+
+// reset forgets every check
+func (t *FailureTracker) reset() {
+	if t == nil {
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.since.Reset()
+	t.failing = map[string]map[string]failingCheck{}
+	t.seeds = map[string]map[string]time.Time{}
+}
+
+// Results returns the checks currently failing, the oldest failures first
+func (t *FailureTracker) Results() []CheckResult {
+	if t == nil {
+		return nil
+	}
+
+	t.mux.RLock()
+	results := []CheckResult{}
+	for _, failing := range t.failing {
+		for _, f := range failing {
+			for _, failure := range f.failures {
+				results = append(results, CheckResult{Failure: failure, FailingSince: f.since})
+			}
+		}
+	}
+	t.mux.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].FailingSince.Equal(results[j].FailingSince) {
+			return results[i].FailingSince.Before(results[j].FailingSince)
+		}
+		if results[i].UID != results[j].UID {
+			return results[i].UID < results[j].UID
+		}
+		if results[i].Check != results[j].Check {
+			return results[i].Check < results[j].Check
+		}
+		return results[i].Message < results[j].Message
+	})
+	return results
+}
+
+// ResultsHandler serves the checks currently failing as JSON. The results can be
+// filtered with the namespace and check query parameters.
+func (t *FailureTracker) ResultsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.URL.Query().Get("namespace")
+		check := r.URL.Query().Get("check")
+
+		results := []CheckResult{}
+		for _, result := range t.Results() {
+			if namespace != "" && result.Namespace != namespace {
+				continue
+			}
+			if check != "" && result.Check != check {
+				continue
+			}
+			results = append(results, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package validations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	promUtils "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFailureTracker(now *time.Time) *FailureTracker {
+	t := NewFailureTracker(MetricLabelsConfig{})
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func sinceValue(tracker *FailureTracker, req Request, check string) float64 {
+	labels := req.ToPromLabels()
+	labels["check"] = check
+	return promUtils.ToFloat64(tracker.since.With(labels))
+}
+
+func TestFailureTracker(t *testing.T) {
+	req := Request{Kind: "Deployment", Name: "app", Namespace: "ns", UID: "app-uid"}
+	otherReq := Request{Kind: "Deployment", Name: "other", Namespace: "other-ns", UID: "other-uid"}
+	failure := func(req Request, check string) Failure {
+		return Failure{Check: check, Kind: req.Kind, Name: req.Name, Namespace: req.Namespace, UID: req.UID}
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("it keeps the first failure time while the check keeps failing", func(t *testing.T) {
+		// Given
+		now := start
+		tracker := newTestFailureTracker(&now)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+
+		// When
+		now = start.Add(24 * time.Hour)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+		tracker.set(req, "check-b", []Failure{failure(req, "check-b")})
+
+		// Assert
+		assert.Equal(t, float64(start.Unix()), sinceValue(tracker, req, "check-a"))
+		assert.Equal(t, float64(now.Unix()), sinceValue(tracker, req, "check-b"))
+		results := tracker.Results()
+		assert.Len(t, results, 2)
+		assert.Equal(t, "check-a", results[0].Check)
+		assert.Equal(t, start, results[0].FailingSince)
+	})
+
+	t.Run("each failure of a check is a result", func(t *testing.T) {
+		// Given
+		now := start
+		tracker := newTestFailureTracker(&now)
+		app, sidecar := failure(req, "check-a"), failure(req, "check-a")
+		app.Message, sidecar.Message = `container "app"`, `container "sidecar"`
+
+		// When
+		tracker.set(req, "check-a", []Failure{sidecar, app})
+
+		// Assert
+		assert.Equal(t, 1, promUtils.CollectAndCount(tracker.since))
+		results := tracker.Results()
+		assert.Len(t, results, 2)
+		assert.Equal(t, app, results[0].Failure)
+		assert.Equal(t, start, results[1].FailingSince)
+	})
+
+	t.Run("a fixed check starts over when failing again", func(t *testing.T) {
+		// Given
+		now := start
+		tracker := newTestFailureTracker(&now)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+		tracker.set(req, "check-b", []Failure{failure(req, "check-b")})
+
+		// When
+		tracker.retain(req.UID, map[string]struct{}{"check-b": {}})
+		now = start.Add(time.Hour)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+
+		// Assert
+		assert.Equal(t, 2, promUtils.CollectAndCount(tracker.since))
+		assert.Equal(t, float64(now.Unix()), sinceValue(tracker, req, "check-a"))
+	})
+
+	t.Run("it forgets deleted objects and checks", func(t *testing.T) {
+		// Given
+		now := start
+		tracker := newTestFailureTracker(&now)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+		tracker.set(req, "check-b", []Failure{failure(req, "check-b")})
+		tracker.set(otherReq, "check-a", []Failure{failure(otherReq, "check-a")})
+
+		// When
+		tracker.deleteChecks([]string{"check-b"})
+		tracker.deleteObject(otherReq.UID)
+
+		// Assert
+		assert.Equal(t, 1, promUtils.CollectAndCount(tracker.since))
+		assert.Len(t, tracker.Results(), 1)
+	})
+
+	t.Run("the results can be filtered by namespace", func(t *testing.T) {
+		// Given
+		now := start
+		tracker := newTestFailureTracker(&now)
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+		tracker.set(otherReq, "check-a", []Failure{failure(otherReq, "check-a")})
+		rec := httptest.NewRecorder()
+
+		// When
+		httpReq := httptest.NewRequest(http.MethodGet, "/results?namespace=ns", nil)
+		tracker.ResultsHandler().ServeHTTP(rec, httpReq)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var results []CheckResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		assert.Len(t, results, 1)
+		assert.Equal(t, req.UID, results[0].UID)
+		assert.Equal(t, start, results[0].FailingSince)
+	})
+
+	t.Run("the seeded times are kept by the checks still failing", func(t *testing.T) {
+		// Given
+		seeded := start.Add(-24 * time.Hour)
+		now := start
+		tracker := newTestFailureTracker(&now)
+		tracker.Seed(map[string]map[string]time.Time{
+			req.UID:      {"check-a": seeded, "check-b": seeded},
+			otherReq.UID: {"check-a": seeded},
+		})
+
+		// When
+		tracker.set(req, "check-a", []Failure{failure(req, "check-a")})
+		tracker.retain(req.UID, map[string]struct{}{"check-a": {}})
+		tracker.set(req, "check-b", []Failure{failure(req, "check-b")})
+
+		// Assert
+		assert.Equal(t, float64(seeded.Unix()), sinceValue(tracker, req, "check-a"))
+		assert.Equal(t, float64(start.Unix()), sinceValue(tracker, req, "check-b"),
+			"the seed of a check fixed since the restart is forgotten")
+		assert.Equal(t, map[string]map[string]time.Time{otherReq.UID: {"check-a": seeded}}, tracker.seeds)
+	})
+}
@@ -0,0 +1,78 @@
+package validations
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/extract"
+	"golang.stackrox.io/kube-linter/pkg/k8sutil"
+)
+
+// Failure describes a single check reported as failing for an object
+type Failure struct {
+	Check     string `json:"check"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+	Message   string `json:"message"`
+	// Container is the name of the container the failure refers to, if any
+	Container string `json:"container,omitempty"`
+	// ContainerPath locates the container in the pod spec of the object, e.g. containers[1]
+	ContainerPath string `json:"containerPath,omitempty"`
+	// RootOwnerKind and RootOwnerName identify the top-most controller of the object,
+	// the object itself when it has none
+	RootOwnerKind string `json:"rootOwnerKind,omitempty"`
+	RootOwnerName string `json:"rootOwnerName,omitempty"`
+	// Team and Contact identify the team owning the object, see OwnershipConfig
+	Team    string `json:"team,omitempty"`
+	Contact string `json:"contact,omitempty"`
+	// Source is the GitOps application the object is deployed from, if any
+	Source *Source `json:"source,omitempty"`
+}
+
+// FailureReporter receives the failures found by each validation run
+type FailureReporter interface {
+	ReportFailures(failures []Failure)
+}
+
+// NewFailureFromReport converts a kube-linter report into a Failure
+func NewFailureFromReport(report diagnostic.WithContext) Failure {
+	obj := report.Object.K8sObject
+	container := containerFromMessage(report.Diagnostic.Message)
+
+	return Failure{
+		Check:         report.Check,
+		Kind:          obj.GetObjectKind().GroupVersionKind().Kind,
+		Name:          obj.GetName(),
+		Namespace:     obj.GetNamespace(),
+		UID:           string(obj.GetUID()),
+		Message:       report.Diagnostic.Message,
+		Container:     container,
+		ContainerPath: containerPath(obj, container),
+	}
+}
+
+// containerPath returns the path of the named container in the pod spec
+// of the object, or an empty string if it cannot be found
+func containerPath(obj k8sutil.Object, container string) string {
+	if container == "" {
+		return ""
+	}
+	podSpec, ok := extract.PodSpec(obj)
+	if !ok {
+		return ""
+	}
+
+	for i, c := range podSpec.Containers {
+		if c.Name == container {
+			return fmt.Sprintf("containers[%d]", i)
+		}
+	}
+	for i, c := range podSpec.InitContainers {
+		if c.Name == container {
+			return fmt.Sprintf("initContainers[%d]", i)
+		}
+	}
+	return ""
+}
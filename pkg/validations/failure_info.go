@@ -0,0 +1,203 @@
+package validations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	failureInfoMetricName = "dvo_check_failure_info"
+	// maxReasonLength is the maximum number of characters of the reason label,
+	// the reason_hash label still telling apart reasons with a common prefix
+	maxReasonLength = 64
+)
+
+// containerRe matches the container name in kube-linter diagnostic messages,
+// e.g. `container "app" has memory limit 0`
+var containerRe = regexp.MustCompile(`container "([^"]+)"`)
+
+// FailureInfoMetric exports the details of each failing check, such as the
+// container involved and the reason reported by kube-linter, as an info metric.
+// The number of series is capped, series beyond the cap are dropped and counted.
+type FailureInfoMetric struct {
+	mux       sync.Mutex
+	maxSeries int
+	// series currently exported, by object UID
+	series  map[string]map[string]prometheus.Labels
+	count   int
+	info    *prometheus.GaugeVec
+	dropped prometheus.Counter
+}
+
+// NewFailureInfoMetric returns a FailureInfoMetric exporting at most maxSeries series,
+// labelled as the check metrics with the given configuration
+func NewFailureInfoMetric(maxSeries int, labels MetricLabelsConfig) *FailureInfoMetric {
+	return &FailureInfoMetric{
+		maxSeries: maxSeries,
+		series:    map[string]map[string]prometheus.Labels{},
+		info: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: failureInfoMetricName,
+				Help: "Details of a check failing for an object. The value is always 1.",
+			}, append(MetricLabelNames(labels), "check", "container", "reason", "reason_hash")),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: failureInfoMetricName + "_dropped_total",
+			Help: "Number of failure info series not exported because of the cardinality cap",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *FailureInfoMetric) Describe(ch chan<- *prometheus.Desc) {
+	m.info.Describe(ch)
+	m.dropped.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *FailureInfoMetric) Collect(ch chan<- prometheus.Metric) {
+	m.info.Collect(ch)
+	m.dropped.Collect(ch)
+}
+
+// set exports the failure of the check for the object identified by the request
+func (m *FailureInfoMetric) set(req Request, check, message string) {
+	if m == nil {
+		return
+	}
+
+	labels := req.ToPromLabels()
+	labels["check"] = check
+	labels["container"] = containerFromMessage(message)
+	labels["reason"] = truncateReason(message)
+	labels["reason_hash"] = hashReason(message)
+	key := failureInfoKey(check, message)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, ok := m.series[req.UID][key]; ok {
+		return
+	}
+	if m.count >= m.maxSeries {
+		m.dropped.Inc()
+		return
+	}
+
+	if _, ok := m.series[req.UID]; !ok {
+		m.series[req.UID] = map[string]prometheus.Labels{}
+	}
+	m.series[req.UID][key] = labels
+	m.count++
+	m.info.With(labels).Set(1)
+}
+
+// deleteObject removes the series of the object with the given UID
+func (m *FailureInfoMetric) deleteObject(uid string) {
+	if m == nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, labels := range m.series[uid] {
+		m.info.Delete(labels)
+		m.count--
+	}
+	delete(m.series, uid)
+}
+
+// retain removes the series of the object with the given UID whose keys,
+// see failureInfoKey, are not part of the given ones
+func (m *FailureInfoMetric) retain(uid string, keys map[string]struct{}) {
+	if m == nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for key, labels := range m.series[uid] {
+		if _, ok := keys[key]; !ok {
+			m.info.Delete(labels)
+			delete(m.series[uid], key)
+			m.count--
+		}
+	}
+	if len(m.series[uid]) == 0 {
+		delete(m.series, uid)
+	}
+}
+
+// deleteChecks removes the series of the given checks
+func (m *FailureInfoMetric) deleteChecks(checks []string) {
+	if m == nil || len(checks) == 0 {
+		return
+	}
+
+	deleted := make(map[string]struct{}, len(checks))
+	for _, check := range checks {
+		deleted[check] = struct{}{}
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for uid, series := range m.series {
+		for key, labels := range series {
+			if _, ok := deleted[labels["check"]]; ok {
+				m.info.Delete(labels)
+				delete(series, key)
+				m.count--
+			}
+		}
+		if len(series) == 0 {
+			delete(m.series, uid)
+		}
+	}
+}
+
+// reset removes all the series
+func (m *FailureInfoMetric) reset() {
+	if m == nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.info.Reset()
+	m.series = map[string]map[string]prometheus.Labels{}
+	m.count = 0
+}
+
+// failureInfoKey identifies the series of a failure among the ones of its object
+func failureInfoKey(check, message string) string {
+	return strings.Join([]string{check, containerFromMessage(message), hashReason(message)}, "/")
+}
+
+// containerFromMessage returns the name of the container a diagnostic message refers to, if any
+func containerFromMessage(message string) string {
+	if matches := containerRe.FindStringSubmatch(message); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+func truncateReason(message string) string {
+	if utf8.RuneCountInString(message) <= maxReasonLength {
+		return message
+	}
+	return string([]rune(message)[:maxReasonLength])
+}
+
+func hashReason(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:4])
+}
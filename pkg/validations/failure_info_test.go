@@ -0,0 +1,107 @@
+package validations
+
+import (
+	"strings"
+	"testing"
+
+	promUtils "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureInfoMetric(t *testing.T) {
+	req := Request{Kind: "Deployment", Name: "app", Namespace: "ns", UID: "app-uid"}
+	otherReq := Request{Kind: "Deployment", Name: "other", Namespace: "ns", UID: "other-uid"}
+
+	t.Run("it exports the container and the reason of the failure", func(t *testing.T) {
+		// Given
+		m := NewFailureInfoMetric(10, MetricLabelsConfig{})
+
+		// When
+		m.set(req, "unset-memory-requirements", `container "nginx" has memory limit 0`)
+
+		// Assert
+		assert.Equal(t, 1, promUtils.CollectAndCount(m.info))
+		labels := req.ToPromLabels()
+		labels["check"] = "unset-memory-requirements"
+		labels["container"] = "nginx"
+		labels["reason"] = `container "nginx" has memory limit 0`
+		labels["reason_hash"] = hashReason(`container "nginx" has memory limit 0`)
+		assert.Equal(t, float64(1), promUtils.ToFloat64(m.info.With(labels)))
+	})
+
+	t.Run("series beyond the cap are dropped", func(t *testing.T) {
+		// Given
+		m := NewFailureInfoMetric(2, MetricLabelsConfig{})
+
+		// When
+		m.set(req, "check-a", "reason")
+		m.set(req, "check-a", "reason")
+		m.set(req, "check-b", "reason")
+		m.set(otherReq, "check-a", "reason")
+
+		// Assert
+		assert.Equal(t, 2, promUtils.CollectAndCount(m.info))
+		assert.Equal(t, float64(1), promUtils.ToFloat64(m.dropped))
+
+		// a deleted object frees its series
+		m.deleteObject(req.UID)
+		m.set(otherReq, "check-a", "reason")
+		assert.Equal(t, 1, promUtils.CollectAndCount(m.info))
+	})
+
+	t.Run("it deletes the series of the given checks", func(t *testing.T) {
+		// Given
+		m := NewFailureInfoMetric(10, MetricLabelsConfig{})
+		m.set(req, "check-a", "reason")
+		m.set(req, "check-b", "reason")
+		m.set(otherReq, "check-a", "reason")
+
+		// When
+		m.deleteChecks([]string{"check-a"})
+
+		// Assert
+		assert.Equal(t, 1, promUtils.CollectAndCount(m.info))
+		assert.Equal(t, 1, m.count)
+	})
+
+	t.Run("it keeps the series of the object still failing", func(t *testing.T) {
+		// Given
+		m := NewFailureInfoMetric(10, MetricLabelsConfig{})
+		m.set(req, "check-a", "reason")
+		m.set(req, "check-b", "reason")
+		m.set(otherReq, "check-b", "reason")
+
+		// When
+		m.retain(req.UID, map[string]struct{}{failureInfoKey("check-a", "reason"): {}})
+
+		// Assert
+		assert.Equal(t, 2, promUtils.CollectAndCount(m.info))
+		assert.Equal(t, 2, m.count)
+		m.retain(req.UID, nil)
+		assert.Equal(t, 1, promUtils.CollectAndCount(m.info))
+		assert.NotContains(t, m.series, req.UID)
+	})
+
+	t.Run("a nil metric is a no-op", func(t *testing.T) {
+		var m *FailureInfoMetric
+
+		assert.NotPanics(t, func() {
+			m.set(req, "check-a", "reason")
+			m.deleteObject(req.UID)
+			m.retain(req.UID, nil)
+			m.deleteChecks([]string{"check-a"})
+			m.reset()
+		})
+	})
+}
+
+func TestFailureReasonLabels(t *testing.T) {
+	long := strings.Repeat("a", maxReasonLength+10)
+
+	assert.Equal(t, "nginx", containerFromMessage(`container "nginx" does not have a read-only root file system`))
+	assert.Empty(t, containerFromMessage("object has 1 replica but minimum required replicas is 3"))
+	assert.Equal(t, long[:maxReasonLength], truncateReason(long))
+	assert.Equal(t, "short", truncateReason("short"))
+	assert.NotEqual(t, hashReason(long), hashReason(long[:maxReasonLength]))
+	assert.Len(t, hashReason(long), 8)
+}
@@ -0,0 +1,60 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/validations/templates/image"
+)
+
+// ImagesConfig sets the parameters of the image checks. The registries may be given as a
+// registry, e.g. quay.io, or a repository, e.g. quay.io/example, the images without
+// registry being pulled from docker.io.
+type ImagesConfig struct {
+	// AllowedRegistries are the only registries the images may be pulled from, all of them
+	// when nil. It is empty, allowing none of them, when the layers have no registry in common.
+	AllowedRegistries []string `json:"allowedRegistries"`
+	// DeniedRegistries are the registries the images may not be pulled from,
+	// taking precedence over the allowed ones
+	DeniedRegistries []string `json:"deniedRegistries,omitempty"`
+	// DigestExemptRegistries are the registries whose images do not need to be pinned by digest
+	DigestExemptRegistries []string `json:"digestExemptRegistries,omitempty"`
+	// MutableTags are the tags considered mutable, latest when empty
+	MutableTags []string `json:"mutableTags,omitempty"`
+}
+
+// Validate returns an error if one of the registries or tags is empty
+func (c ImagesConfig) Validate() error {
+	lists := map[string][]string{
+		"allowedRegistries":      c.AllowedRegistries,
+		"deniedRegistries":       c.DeniedRegistries,
+		"digestExemptRegistries": c.DigestExemptRegistries,
+		"mutableTags":            c.MutableTags,
+	}
+	for name, values := range lists {
+		for _, v := range values {
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("images %s cannot hold an empty value", name)
+			}
+		}
+	}
+	return nil
+}
+
+// registriesParams returns the parameters of the image-registries template
+func (c ImagesConfig) registriesParams() map[string]interface{} {
+	return map[string]interface{}{
+		image.AllowedRegistriesParam: c.AllowedRegistries,
+		image.DeniedRegistriesParam:  c.DeniedRegistries,
+	}
+}
+
+// digestParams returns the parameters of the image-digest template
+func (c ImagesConfig) digestParams() map[string]interface{} {
+	return map[string]interface{}{image.ExemptRegistriesParam: c.DigestExemptRegistries}
+}
+
+// tagsParams returns the parameters of the templates of the mutable tags
+func (c ImagesConfig) tagsParams() map[string]interface{} {
+	return map[string]interface{}{image.MutableTagsParam: c.MutableTags}
+}
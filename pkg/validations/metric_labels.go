@@ -0,0 +1,171 @@
+package validations
+
+import (
+	"maps"
+	"regexp"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// baseMetricLabels are the labels identifying the object of every check metric
+var baseMetricLabels = []string{"namespace_uid", "namespace", "uid", "name", "kind"}
+
+var invalidLabelNameCharsRe = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// MetricLabelsConfig selects the object and namespace metadata promoted to metric labels,
+// so the metrics can be routed without joining other sources such as kube-state-metrics
+type MetricLabelsConfig struct {
+	// ObjectLabels are the keys of the object labels exported as label_<key>
+	ObjectLabels []string `json:"objectLabels,omitempty"`
+	// ObjectAnnotations are the keys of the object annotations exported as annotation_<key>
+	ObjectAnnotations []string `json:"objectAnnotations,omitempty"`
+	// NamespaceLabels are the keys of the namespace labels exported as namespace_label_<key>
+	NamespaceLabels []string `json:"namespaceLabels,omitempty"`
+	// ScaledToZero adds the scaled_to_zero label, telling whether the workload is scaled to zero
+	ScaledToZero bool `json:"scaledToZero,omitempty"`
+	// RootOwner adds the root_owner_kind and root_owner_name labels, identifying the
+	// top-most controller of the object, or the object itself when it has none
+	RootOwner bool `json:"rootOwner,omitempty"`
+	// Team adds the team label, naming the team owning the object, see OwnershipConfig
+	Team bool `json:"team,omitempty"`
+}
+
+// MetricLabelNames returns the names of the labels of the check metrics
+// for the given configuration
+func MetricLabelNames(cfg MetricLabelsConfig) []string {
+	names := append([]string(nil), baseMetricLabels...)
+	for _, l := range cfg.labels() {
+		names = append(names, l.name)
+	}
+	if cfg.ScaledToZero {
+		names = append(names, scaledToZeroLabel)
+	}
+	if cfg.RootOwner {
+		names = append(names, rootOwnerKindLabel, rootOwnerNameLabel)
+	}
+	if cfg.Team {
+		names = append(names, teamLabel)
+	}
+	return names
+}
+
+type promotedLabel struct {
+	name   string
+	key    string
+	source func(obj client.Object, namespaceLabels map[string]string) map[string]string
+}
+
+// labels returns the promoted labels. Keys resulting in the same label name
+// once sanitized are exported once, using the first of them.
+func (cfg MetricLabelsConfig) labels() []promotedLabel {
+	var labels []promotedLabel
+	seen := map[string]struct{}{}
+
+	add := func(prefix string, keys []string,
+		source func(client.Object, map[string]string) map[string]string) {
+		for _, key := range keys {
+			name := prefix + invalidLabelNameCharsRe.ReplaceAllString(key, "_")
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			labels = append(labels, promotedLabel{name: name, key: key, source: source})
+		}
+	}
+
+	add("label_", cfg.ObjectLabels, func(obj client.Object, _ map[string]string) map[string]string {
+		return obj.GetLabels()
+	})
+	add("annotation_", cfg.ObjectAnnotations, func(obj client.Object, _ map[string]string) map[string]string {
+		return obj.GetAnnotations()
+	})
+	add("namespace_label_", cfg.NamespaceLabels, func(_ client.Object, ns map[string]string) map[string]string {
+		return ns
+	})
+
+	return labels
+}
+
+// values returns the values of the promoted labels for the given object,
+// empty for the metadata not defined
+func (cfg MetricLabelsConfig) values(obj client.Object, namespaceLabels map[string]string) map[string]string {
+	labels := cfg.labels()
+	if len(labels) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(labels))
+	for _, l := range labels {
+		values[l.name] = l.source(obj, namespaceLabels)[l.key]
+	}
+	return values
+}
+
+// seriesLabels remembers the labels of the series of each object, by UID, when labels are
+// promoted, so that its series can be deleted by their exact labels rather than by scanning
+// every series for a partial match
+type seriesLabels struct {
+	mux    sync.Mutex
+	labels map[string]prometheus.Labels
+}
+
+func newSeriesLabels() *seriesLabels {
+	return &seriesLabels{labels: map[string]prometheus.Labels{}}
+}
+
+// of returns the labels of the series of the object with the given identity, which are
+// its identity when no label is promoted or when it has no series
+func (s *seriesLabels) of(identity prometheus.Labels) prometheus.Labels {
+	if s == nil {
+		return identity
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if labels, ok := s.labels[identity["uid"]]; ok {
+		return labels
+	}
+	return identity
+}
+
+// set records the labels of the series of the object with the given UID and returns
+// the labels recorded previously, if they were different
+func (s *seriesLabels) set(uid string, labels prometheus.Labels) (prometheus.Labels, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	previous, ok := s.labels[uid]
+	s.labels[uid] = labels
+	return previous, ok && !maps.Equal(previous, labels)
+}
+
+// delete forgets the labels of the series of the object with the given UID
+func (s *seriesLabels) delete(uid string) {
+	if s == nil {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.labels, uid)
+}
+
+// reset forgets the labels of the series of every object
+func (s *seriesLabels) reset() {
+	if s == nil {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.labels = map[string]prometheus.Labels{}
+}
@@ -0,0 +1,107 @@
+package validations
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMetricLabelNames(t *testing.T) {
+	// Given
+	cfg := MetricLabelsConfig{
+		ObjectLabels:      []string{"app.kubernetes.io/part-of", "app.kubernetes.io.part-of"},
+		ObjectAnnotations: []string{"example.com/owner-team"},
+		NamespaceLabels:   []string{"team"},
+	}
+
+	// When
+	names := MetricLabelNames(cfg)
+
+	// Assert
+	assert.Equal(t, []string{
+		"namespace_uid", "namespace", "uid", "name", "kind",
+		"label_app_kubernetes_io_part_of",
+		"annotation_example_com_owner_team",
+		"namespace_label_team",
+	}, names)
+	assert.Equal(t, baseMetricLabels, MetricLabelNames(MetricLabelsConfig{}))
+	assert.Equal(t, append(append([]string(nil), baseMetricLabels...), "scaled_to_zero"),
+		MetricLabelNames(MetricLabelsConfig{ScaledToZero: true}))
+	assert.Equal(t, append(append([]string(nil), baseMetricLabels...), "root_owner_kind", "root_owner_name"),
+		MetricLabelNames(MetricLabelsConfig{RootOwner: true}))
+	assert.Equal(t, append(append([]string(nil), baseMetricLabels...), "team"),
+		MetricLabelNames(MetricLabelsConfig{Team: true}))
+}
+
+func TestMetricLabelValues(t *testing.T) {
+	cfg := MetricLabelsConfig{
+		ObjectLabels:      []string{"app.kubernetes.io/part-of"},
+		ObjectAnnotations: []string{"example.com/owner-team"},
+		NamespaceLabels:   []string{"team"},
+	}
+	obj := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"app.kubernetes.io/part-of": "billing"},
+		Annotations: map[string]string{"example.com/owner-team": "payments"},
+	}}
+
+	t.Run("it returns the metadata of the object and its namespace", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"label_app_kubernetes_io_part_of":   "billing",
+			"annotation_example_com_owner_team": "payments",
+			"namespace_label_team":              "sre",
+		}, cfg.values(obj, map[string]string{"team": "sre"}))
+	})
+
+	t.Run("missing metadata is empty", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"label_app_kubernetes_io_part_of":   "",
+			"annotation_example_com_owner_team": "",
+			"namespace_label_team":              "",
+		}, cfg.values(&appsv1.Deployment{}, nil))
+	})
+
+	t.Run("no promoted labels", func(t *testing.T) {
+		assert.Nil(t, MetricLabelsConfig{}.values(obj, nil))
+	})
+}
+
+func TestSeriesLabels(t *testing.T) {
+	identity := prometheus.Labels{"uid": "app", "name": "app"}
+	labels := prometheus.Labels{"uid": "app", "name": "app", "team": "payments"}
+
+	t.Run("the labels of an object without series are its identity", func(t *testing.T) {
+		assert.Equal(t, identity, newSeriesLabels().of(identity))
+	})
+
+	t.Run("the previous labels are returned once they change", func(t *testing.T) {
+		// Given
+		s := newSeriesLabels()
+		_, changed := s.set("app", labels)
+		assert.False(t, changed)
+		_, changed = s.set("app", labels)
+		assert.False(t, changed)
+
+		// When
+		previous, changed := s.set("app", prometheus.Labels{"uid": "app", "name": "app", "team": "billing"})
+
+		// Assert
+		assert.True(t, changed)
+		assert.Equal(t, labels, previous)
+		assert.Equal(t, "billing", s.of(identity)["team"])
+	})
+
+	t.Run("the deleted objects are forgotten", func(t *testing.T) {
+		// Given
+		s := newSeriesLabels()
+		s.set("app", labels)
+
+		// When
+		s.delete("app")
+
+		// Assert
+		assert.Equal(t, identity, s.of(identity))
+	})
+}
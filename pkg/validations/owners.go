@@ -0,0 +1,74 @@
+package validations
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
+)
+
+const (
+	// rootOwnerKindLabel and rootOwnerNameLabel are the metric labels identifying
+	// the top-most controller of the object
+	rootOwnerKindLabel = "root_owner_kind"
+	rootOwnerNameLabel = "root_owner_name"
+)
+
+// Owner identifies a controller of an object
+type Owner struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+}
+
+// OwnerResolver resolves the controllers of the objects
+type OwnerResolver interface {
+	// Owners returns the chain of the controllers of the given object, from its
+	// controller to the top-most one, empty if it has none
+	Owners(ctx context.Context, obj client.Object) []Owner
+}
+
+// directOwners returns the controller of the object as set in its owner references,
+// used when no OwnerResolver is set
+func directOwners(obj client.Object) []Owner {
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil {
+		return nil
+	}
+	return []Owner{{APIVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name, UID: string(ref.UID)}}
+}
+
+// isTopLevel returns false if the object is a deployment-like resource owned, directly or
+// not, by a deployment-like resource, which is validated instead
+func isTopLevel(obj client.Object, owners []Owner) bool {
+	if !utils.IsOwner(obj) {
+		return false
+	}
+	if !utils.IsDeploymentLike(obj.GetObjectKind().GroupVersionKind()) {
+		return true
+	}
+	for _, owner := range owners {
+		if utils.IsDeploymentLike(schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind)) {
+			return false
+		}
+	}
+	return true
+}
+
+// rootOwner returns the top-most controller of the object, the object itself when it has none
+func rootOwner(obj client.Object, owners []Owner) Owner {
+	if len(owners) > 0 {
+		return owners[len(owners)-1]
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return Owner{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+		UID:        string(obj.GetUID()),
+	}
+}
@@ -0,0 +1,39 @@
+package validations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRootOwner(t *testing.T) {
+	// Given
+	controller := true
+	replicaSet := &appsv1.ReplicaSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-5d9c", UID: "rs",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: "app", UID: "deployment",
+					Controller: &controller,
+				},
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app", UID: "deployment"},
+	}
+
+	// When
+	owners := directOwners(replicaSet)
+
+	// Assert
+	assert.Equal(t, []Owner{{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", UID: "deployment"}}, owners)
+	assert.Equal(t, owners[0], rootOwner(replicaSet, owners))
+	assert.Empty(t, directOwners(deployment))
+	assert.Equal(t, owners[0], rootOwner(deployment, nil), "an object without controller is its own root owner")
+}
@@ -0,0 +1,123 @@
+package validations
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// teamLabel is the metric label naming the team owning the object
+const teamLabel = "team"
+
+// OwnershipConfig sets how the validated objects are attributed to teams. The team is taken
+// from the first object annotation defined, then from the first namespace annotation defined,
+// and finally from the mapping.
+type OwnershipConfig struct {
+	// ObjectAnnotations are the keys of the object annotations naming the team, by precedence
+	ObjectAnnotations []string `json:"objectAnnotations,omitempty"`
+	// NamespaceAnnotations are the keys of the namespace annotations naming the team, by precedence
+	NamespaceAnnotations []string `json:"namespaceAnnotations,omitempty"`
+	// Mapping attributes the namespaces to teams in the CODEOWNERS style, one
+	// "<namespace regex> <team> [<contact>]" rule per line, the last matching rule
+	// winning. Blank lines and lines starting with # are ignored.
+	Mapping string `json:"mapping,omitempty"`
+}
+
+// Enabled returns true if the configuration attributes the objects to teams
+func (c OwnershipConfig) Enabled() bool {
+	return len(c.ObjectAnnotations) > 0 || len(c.NamespaceAnnotations) > 0 || strings.TrimSpace(c.Mapping) != ""
+}
+
+// Validate returns an error if the mapping cannot be parsed
+func (c OwnershipConfig) Validate() error {
+	_, err := parseTeamRules(c.Mapping)
+	return err
+}
+
+// Team is the team owning an object
+type Team struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact,omitempty"`
+}
+
+// teamRule attributes the namespaces matching its pattern to a team
+type teamRule struct {
+	namespace *regexp.Regexp
+	team      Team
+}
+
+// parseTeamRules parses the rules of the given mapping. The patterns match the whole
+// namespace name.
+func parseTeamRules(mapping string) ([]teamRule, error) {
+	var rules []teamRule
+	scanner := bufio.NewScanner(strings.NewReader(mapping))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf(
+				"ownership mapping line %d: expected \"<namespace regex> <team> [<contact>]\"", line)
+		}
+
+		re, err := regexp.Compile("^(?:" + fields[0] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("ownership mapping line %d: %w", line, err)
+		}
+		rule := teamRule{namespace: re, team: Team{Name: fields[1]}}
+		if len(fields) == 3 {
+			rule.team.Contact = fields[2]
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ownership is the compiled OwnershipConfig
+type ownership struct {
+	cfg   OwnershipConfig
+	rules []teamRule
+}
+
+func newOwnership(cfg OwnershipConfig) (ownership, error) {
+	rules, err := parseTeamRules(cfg.Mapping)
+	if err != nil {
+		return ownership{}, err
+	}
+	return ownership{cfg: cfg, rules: rules}, nil
+}
+
+// team returns the team owning the given object, empty if it cannot be attributed.
+// The contact of the team is only known from the mapping.
+func (o ownership) team(obj client.Object, namespace Namespace) Team {
+	for _, key := range o.cfg.ObjectAnnotations {
+		if name := obj.GetAnnotations()[key]; name != "" {
+			return o.withContact(Team{Name: name})
+		}
+	}
+	for _, key := range o.cfg.NamespaceAnnotations {
+		if name := namespace.Annotations[key]; name != "" {
+			return o.withContact(Team{Name: name})
+		}
+	}
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].namespace.MatchString(obj.GetNamespace()) {
+			return o.rules[i].team
+		}
+	}
+	return Team{}
+}
+
+// withContact sets the contact of the given team from the last rule of the mapping naming it
+func (o ownership) withContact(team Team) Team {
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].team.Name == team.Name {
+			return o.rules[i].team
+		}
+	}
+	return team
+}
@@ -0,0 +1,50 @@
+package validations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnershipTeam(t *testing.T) {
+	// Given
+	o, err := newOwnership(OwnershipConfig{
+		ObjectAnnotations:    []string{"example.com/team"},
+		NamespaceAnnotations: []string{"example.com/team"},
+		Mapping: `
+# namespace regex, team, contact
+payments-.*     payments  #payments-oncall
+payments-legacy billing
+checkout        checkout  checkout@example.com`,
+	})
+	assert.NoError(t, err)
+	deployment := func(namespace string, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name: "app", Namespace: namespace, Annotations: annotations,
+		}}
+	}
+	annotated := Namespace{Annotations: map[string]string{"example.com/team": "checkout"}}
+
+	// Assert
+	assert.Equal(t, Team{Name: "payments", Contact: "#payments-oncall"},
+		o.team(deployment("payments-api", nil), Namespace{}))
+	assert.Equal(t, Team{Name: "billing"}, o.team(deployment("payments-legacy", nil), Namespace{}),
+		"the last matching rule wins")
+	assert.Equal(t, Team{}, o.team(deployment("payments", nil), Namespace{}),
+		"the patterns match the whole namespace name")
+	assert.Equal(t, Team{Name: "checkout", Contact: "checkout@example.com"},
+		o.team(deployment("payments-api", nil), annotated),
+		"the namespace annotations take precedence over the mapping, the contact coming from it")
+	assert.Equal(t, Team{Name: "search"},
+		o.team(deployment("payments-api", map[string]string{"example.com/team": "search"}), annotated),
+		"the object annotations take precedence over the namespace ones")
+}
+
+func TestOwnershipValidate(t *testing.T) {
+	assert.NoError(t, OwnershipConfig{Mapping: "\n# comment\ndev-.* dev\n"}.Validate())
+	assert.ErrorContains(t, OwnershipConfig{Mapping: "dev-.*"}.Validate(), "ownership mapping line 1")
+	assert.ErrorContains(t, OwnershipConfig{Mapping: "a b\n( dev"}.Validate(), "ownership mapping line 2")
+	assert.False(t, OwnershipConfig{Mapping: "\n"}.Enabled())
+}
@@ -0,0 +1,139 @@
+package validations
+
+import (
+	osappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
+)
+
+// scaledToZeroLabel is the metric label telling whether the object is scaled to zero
+const scaledToZeroLabel = "scaled_to_zero"
+
+// ReplicasConfig sets how the workloads scaled to zero are validated
+type ReplicasConfig struct {
+	// ValidateScaledToZero validates the workloads scaled to zero, which are skipped
+	// otherwise, their metrics being deleted
+	ValidateScaledToZero bool `json:"validateScaledToZero"`
+}
+
+// autoscalerMinReplicas returns the minimum replicas of the autoscalers of the given
+// objects by the workload they target. The minimum replicas of the HPAs default to 1, as
+// in their API. A KEDA ScaledObject setting its minReplicaCount takes precedence over the
+// HPA it manages, while the one not setting it is left to this HPA, if any, or otherwise
+// to the replicas of the workload, rather than defaulting to 0 and skipping the workload.
+func autoscalerMinReplicas(objects []client.Object) map[utils.ScaleTarget]int32 {
+	hpaReplicas := map[utils.ScaleTarget]int32{}
+	scaledObjectReplicas := map[utils.ScaleTarget]int32{}
+	for _, obj := range objects {
+		target, ok := utils.GetScaleTarget(obj)
+		if !ok {
+			continue
+		}
+
+		switch o := obj.(type) {
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			hpaReplicas[target] = valueOr(o.Spec.MinReplicas, 1)
+		case *autoscalingv1.HorizontalPodAutoscaler:
+			hpaReplicas[target] = valueOr(o.Spec.MinReplicas, 1)
+		case *unstructured.Unstructured:
+			if o.GroupVersionKind().GroupKind() != utils.KEDAScaledObject {
+				continue
+			}
+			minReplicas, found, err := unstructured.NestedInt64(o.Object, "spec", "minReplicaCount")
+			if found && err == nil {
+				scaledObjectReplicas[target] = int32(minReplicas)
+			}
+		}
+	}
+
+	for target, minReplicas := range scaledObjectReplicas {
+		hpaReplicas[target] = minReplicas
+	}
+	return hpaReplicas
+}
+
+func valueOr(value *int32, defaultValue int32) int32 {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
+// workload returns the kind and the replicas of the given workload. The returned
+// boolean is false for the kinds without replicas.
+func workload(obj client.Object) (string, *int32, bool) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return "Deployment", o.Spec.Replicas, true
+	case *appsv1.StatefulSet:
+		return "StatefulSet", o.Spec.Replicas, true
+	case *appsv1.ReplicaSet:
+		return "ReplicaSet", o.Spec.Replicas, true
+	case *corev1.ReplicationController:
+		return "ReplicationController", o.Spec.Replicas, true
+	case *osappsv1.DeploymentConfig:
+		return "DeploymentConfig", &o.Spec.Replicas, true
+	default:
+		return "", nil, false
+	}
+}
+
+// replicas returns the replicas of the given workload, which are the minimum
+// replicas of its autoscaler if it has one. The returned boolean is false for the
+// kinds without replicas. Unset replicas default to 1, as in the API.
+func replicas(obj client.Object, minReplicas map[utils.ScaleTarget]int32) (int32, bool) {
+	kind, specReplicas, ok := workload(obj)
+	if !ok {
+		return 0, false
+	}
+	if autoscaled, ok := minReplicas[utils.ScaleTarget{Kind: kind, Name: obj.GetName()}]; ok {
+		return autoscaled, true
+	}
+	return valueOr(specReplicas, 1), true
+}
+
+// withAutoscalerReplicas returns the given object, or a copy of it whose replicas are
+// the minimum replicas of its autoscaler, so the checks of the replicas, such as
+// minimum-three-replicas, are evaluated against the replicas the autoscaler guarantees
+func withAutoscalerReplicas(obj client.Object, minReplicas map[utils.ScaleTarget]int32) client.Object {
+	kind, _, ok := workload(obj)
+	if !ok {
+		return obj
+	}
+	n, ok := minReplicas[utils.ScaleTarget{Kind: kind, Name: obj.GetName()}]
+	if !ok {
+		return obj
+	}
+
+	cp := obj.DeepCopyObject().(client.Object)
+	switch o := cp.(type) {
+	case *appsv1.Deployment:
+		o.Spec.Replicas = &n
+	case *appsv1.StatefulSet:
+		o.Spec.Replicas = &n
+	case *appsv1.ReplicaSet:
+		o.Spec.Replicas = &n
+	case *corev1.ReplicationController:
+		o.Spec.Replicas = &n
+	case *osappsv1.DeploymentConfig:
+		o.Spec.Replicas = n
+	}
+	return cp
+}
+
+// scaledToZero returns the UIDs of the workloads of the given objects which are scaled to zero
+func scaledToZero(objects []client.Object, minReplicas map[utils.ScaleTarget]int32) map[string]struct{} {
+	zero := map[string]struct{}{}
+	for _, obj := range objects {
+		if n, ok := replicas(obj, minReplicas); ok && n <= 0 {
+			zero[string(obj.GetUID())] = struct{}{}
+		}
+	}
+	return zero
+}
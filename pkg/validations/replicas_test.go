@@ -0,0 +1,118 @@
+package validations
+
+import (
+	"context"
+	"testing"
+
+	osappsv1 "github.com/openshift/api/apps/v1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestScaledToZero(t *testing.T) {
+	zero, one := int32(0), int32(1)
+	deployment := func(name string, replicas *int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas},
+		}
+	}
+	hpa := func(target string, minReplicas *int32) *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: target, UID: types.UID("hpa-" + target)},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+					Kind: "Deployment", Name: target,
+				},
+				MinReplicas: minReplicas,
+			},
+		}
+	}
+
+	scaledObject := func(target string, minReplicaCount interface{}) *unstructured.Unstructured {
+		spec := map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"name": target},
+		}
+		if minReplicaCount != nil {
+			spec["minReplicaCount"] = minReplicaCount
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "keda.sh/v1alpha1",
+			"kind":       "ScaledObject",
+			"metadata":   map[string]interface{}{"name": target},
+			"spec":       spec,
+		}}
+	}
+
+	// Given
+	objects := []client.Object{
+		deployment("scaled-down", &zero),
+		deployment("running", &one),
+		deployment("defaulted", nil),
+		deployment("autoscaled", &zero),
+		hpa("autoscaled", nil),
+		deployment("autoscaled-to-zero", &one),
+		hpa("autoscaled-to-zero", &zero),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "statefulset", UID: "statefulset"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &zero},
+		},
+		&osappsv1.DeploymentConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "deploymentconfig", UID: "deploymentconfig"},
+			Spec:       osappsv1.DeploymentConfigSpec{Replicas: 2},
+		},
+		deployment("keda-scaled", &one),
+		scaledObject("keda-scaled", nil),
+		deployment("keda-to-zero", &one),
+		scaledObject("keda-to-zero", int64(0)),
+		deployment("keda-with-hpa", &zero),
+		scaledObject("keda-with-hpa", nil),
+		hpa("keda-with-hpa", &one),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "pod"}},
+	}
+
+	// When
+	minReplicas := autoscalerMinReplicas(objects)
+	scaled := scaledToZero(objects, minReplicas)
+
+	// Assert
+	assert.Equal(t, map[string]struct{}{
+		"scaled-down":        {},
+		"autoscaled-to-zero": {},
+		"statefulset":        {},
+		"keda-to-zero":       {},
+	}, scaled, "the ScaledObjects without minReplicaCount are left to the HPA or the workload")
+
+	autoscaled := withAutoscalerReplicas(objects[3], minReplicas).(*appsv1.Deployment)
+	assert.Equal(t, int32(1), *autoscaled.Spec.Replicas, "the replicas are the HPA minReplicas")
+	assert.Equal(t, zero, *objects[3].(*appsv1.Deployment).Spec.Replicas, "the object is left unchanged")
+	assert.Same(t, objects[1], withAutoscalerReplicas(objects[1], minReplicas))
+}
+
+func TestEvaluateObjectsKeepsScaledToZeroMetrics(t *testing.T) {
+	zero := int32(0)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "scaled-down", Namespace: "ns", UID: "scaled-down"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &zero},
+	}
+	req := NewRequestFromObject(deployment)
+
+	// Given
+	tracker := NewFailureTracker(MetricLabelsConfig{})
+	tracker.set(req, "check-a", []Failure{{Check: "check-a", UID: req.UID}})
+	ve := &validationEngine{failureTracker: tracker}
+
+	// When
+	failures, err := ve.EvaluateObjects(context.Background(), []client.Object{deployment}, Namespace{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, failures)
+	assert.Len(t, tracker.Results(), 1, "the failures of the workload are left untouched")
+}
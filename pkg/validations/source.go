@@ -0,0 +1,106 @@
+package validations
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The tools deploying the objects, see Source
+const (
+	SourceToolArgoCD = "argocd"
+	SourceToolFlux   = "flux"
+	SourceToolHelm   = "helm"
+)
+
+// The tracking metadata set by the GitOps tools on the objects they deploy
+const (
+	argoCDTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+	argoCDInstanceLabel        = "argocd.argoproj.io/instance"
+	fluxKustomizationNameLabel = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizationNsLabel   = "kustomize.toolkit.fluxcd.io/namespace"
+	fluxHelmReleaseNameLabel   = "helm.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNsLabel     = "helm.toolkit.fluxcd.io/namespace"
+	helmReleaseNameAnnotation  = "meta.helm.sh/release-name"
+	helmReleaseNsAnnotation    = "meta.helm.sh/release-namespace"
+)
+
+// DefaultArgoCDNamespace is the namespace Argo CD is installed in by default, where the
+// Applications whose tracking metadata does not tell their namespace are looked up
+const DefaultArgoCDNamespace = "argocd"
+
+// Source identifies the GitOps application an object is deployed from
+type Source struct {
+	// Tool is the tool deploying the object, one of SourceToolArgoCD, SourceToolFlux or SourceToolHelm
+	Tool string `json:"tool"`
+	// Kind is the kind of the application, Application for Argo CD, Kustomization or
+	// HelmRelease for Flux, empty for the Helm releases
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// RepoURL and Path locate the manifests of the object in its repository, the path
+	// being the chart for the Helm charts, when they are known
+	RepoURL string `json:"repoURL,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// SourceResolver resolves the GitOps applications the objects are deployed from
+type SourceResolver interface {
+	// Source returns the application the given object is deployed from, nil if none
+	Source(ctx context.Context, obj client.Object) *Source
+}
+
+// TrackedSources resolves the GitOps applications from the tracking metadata of the objects
+// only, the repository of the applications being unknown
+type TrackedSources struct {
+	// ArgoCDNamespace is the namespace Argo CD is installed in, e.g. openshift-gitops
+	ArgoCDNamespace string
+}
+
+// Source implements SourceResolver
+func (s TrackedSources) Source(_ context.Context, obj client.Object) *Source {
+	return TrackedSource(obj, s.ArgoCDNamespace)
+}
+
+// TrackedSource returns the application the given object is deployed from, as told by
+// the tracking metadata of Argo CD, Flux and Helm, in this order, nil if it has none.
+// The Argo CD Applications whose namespace is not told are in the given namespace, the
+// one Argo CD is installed in. The repository of the application is not known from the metadata.
+func TrackedSource(obj client.Object, argoCDNamespace string) *Source {
+	labels, annotations := obj.GetLabels(), obj.GetAnnotations()
+
+	// the tracking id is "<application>:<group>/<kind>:<namespace>/<name>"
+	if id := annotations[argoCDTrackingIDAnnotation]; id != "" {
+		return argoCDSource(strings.SplitN(id, ":", 2)[0], argoCDNamespace)
+	}
+	if name := labels[argoCDInstanceLabel]; name != "" {
+		return argoCDSource(name, argoCDNamespace)
+	}
+	if name := labels[fluxKustomizationNameLabel]; name != "" {
+		return &Source{
+			Tool: SourceToolFlux, Kind: "Kustomization",
+			Namespace: labels[fluxKustomizationNsLabel], Name: name,
+		}
+	}
+	if name := labels[fluxHelmReleaseNameLabel]; name != "" {
+		return &Source{
+			Tool: SourceToolFlux, Kind: "HelmRelease",
+			Namespace: labels[fluxHelmReleaseNsLabel], Name: name,
+		}
+	}
+	if name := annotations[helmReleaseNameAnnotation]; name != "" {
+		return &Source{Tool: SourceToolHelm, Namespace: annotations[helmReleaseNsAnnotation], Name: name}
+	}
+	return nil
+}
+
+// argoCDSource returns the source of the given Argo CD Application, named
+// "<namespace>_<name>" when it is not in the Argo CD namespace
+func argoCDSource(application, argoCDNamespace string) *Source {
+	namespace, name, ok := strings.Cut(application, "_")
+	if !ok {
+		namespace, name = argoCDNamespace, application
+	}
+	return &Source{Tool: SourceToolArgoCD, Kind: "Application", Namespace: namespace, Name: name}
+}
@@ -0,0 +1,93 @@
+package validations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTrackedSource(t *testing.T) {
+	deployment := func(labels, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name: "app", Namespace: "test", Labels: labels, Annotations: annotations,
+		}}
+	}
+	helm := map[string]string{
+		"meta.helm.sh/release-name":      "app",
+		"meta.helm.sh/release-namespace": "test",
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		expected    *Source
+	}{
+		{
+			name:     "untracked",
+			expected: nil,
+		},
+		{
+			name: "argo cd tracking id",
+			annotations: map[string]string{
+				"argocd.argoproj.io/tracking-id": "team-apps_app:apps/Deployment:test/app",
+			},
+			expected: &Source{
+				Tool:      SourceToolArgoCD,
+				Kind:      "Application",
+				Namespace: "team-apps",
+				Name:      "app",
+			},
+		},
+		{
+			name:        "argo cd instance label, along with helm",
+			labels:      map[string]string{"argocd.argoproj.io/instance": "app"},
+			annotations: helm,
+			expected: &Source{
+				Tool:      SourceToolArgoCD,
+				Kind:      "Application",
+				Namespace: "argocd",
+				Name:      "app",
+			},
+		},
+		{
+			name: "flux kustomization",
+			labels: map[string]string{
+				"kustomize.toolkit.fluxcd.io/name":      "apps",
+				"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+			},
+			expected: &Source{
+				Tool:      SourceToolFlux,
+				Kind:      "Kustomization",
+				Namespace: "flux-system",
+				Name:      "apps",
+			},
+		},
+		{
+			name: "flux helm release, along with helm",
+			labels: map[string]string{
+				"helm.toolkit.fluxcd.io/name":      "app",
+				"helm.toolkit.fluxcd.io/namespace": "test",
+			},
+			annotations: helm,
+			expected:    &Source{Tool: SourceToolFlux, Kind: "HelmRelease", Namespace: "test", Name: "app"},
+		},
+		{
+			name:        "helm release",
+			annotations: helm,
+			expected:    &Source{Tool: SourceToolHelm, Namespace: "test", Name: "app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			source := TrackedSource(deployment(tt.labels, tt.annotations), DefaultArgoCDNamespace)
+
+			// Assert
+			assert.Equal(t, tt.expected, source)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package hpa
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+	"golang.stackrox.io/kube-linter/pkg/templates"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
+)
+
+// ConflictingTemplateKey is the key of the template flagging the HPAs scaling
+// the same workload as another HPA
+const ConflictingTemplateKey = "conflicting-hpas"
+
+func init() {
+	templates.Register(check.Template{
+		HumanName: "Conflicting HorizontalPodAutoscalers",
+		Key:       ConflictingTemplateKey,
+		Description: "Flag HorizontalPodAutoscalers scaling the same workload " +
+			"as another HorizontalPodAutoscaler",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.HorizontalPodAutoscaler},
+		},
+		ParseAndValidateParams: noParams,
+		Instantiate: func(interface{}) (check.Func, error) {
+			return conflicting, nil
+		},
+	})
+}
+
+// noParams parses the parameters of the templates without parameters
+func noParams(map[string]interface{}) (interface{}, error) {
+	return struct{}{}, nil
+}
+
+// conflicting reports the other HPAs of the lint context scaling the workload of the given HPA,
+// KEDA included, as its ScaledObjects manage an HPA of their own
+func conflicting(lintCtx lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+	target, ok := utils.GetScaleTarget(object.K8sObject)
+	if !ok {
+		return nil
+	}
+
+	var diagnostics []diagnostic.Diagnostic
+	for _, other := range lintCtx.Objects() {
+		if !utils.IsHorizontalPodAutoscaler(other.K8sObject) ||
+			other.K8sObject.GetUID() == object.K8sObject.GetUID() {
+			continue
+		}
+		if otherTarget, ok := utils.GetScaleTarget(other.K8sObject); ok && otherTarget == target {
+			diagnostics = append(diagnostics, diagnostic.Diagnostic{
+				Message: fmt.Sprintf("%s %q is also scaled by the HorizontalPodAutoscaler %q",
+					target.Kind, target.Name, other.K8sObject.GetName()),
+			})
+		}
+	}
+	return diagnostics
+}
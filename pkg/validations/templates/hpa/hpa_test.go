@@ -0,0 +1,101 @@
+package hpa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type testLintContext []lintcontext.Object
+
+func (c testLintContext) Objects() []lintcontext.Object {
+	return c
+}
+
+func (c testLintContext) InvalidObjects() []lintcontext.InvalidObject {
+	return nil
+}
+
+func testHPA(name, target string, metrics ...autoscalingv2.MetricSpec) lintcontext.Object {
+	return lintcontext.Object{K8sObject: &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: target},
+			Metrics:        metrics,
+		},
+	}}
+}
+
+func testDeployment(name string, containers ...corev1.Container) lintcontext.Object {
+	return lintcontext.Object{K8sObject: &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: containers},
+		}},
+	}}
+}
+
+func messages(diagnostics []diagnostic.Diagnostic) []string {
+	var messages []string
+	for _, d := range diagnostics {
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
+
+func TestConflicting(t *testing.T) {
+	// Given
+	first := testHPA("first", "app")
+	second := testHPA("second", "app")
+	other := testHPA("other", "other")
+	lintCtx := testLintContext{testDeployment("app"), first, second, other}
+
+	// Assert
+	assert.Equal(t, []string{`Deployment "app" is also scaled by the HorizontalPodAutoscaler "second"`},
+		messages(conflicting(lintCtx, first)))
+	assert.Empty(t, conflicting(lintCtx, other))
+}
+
+func TestResourceRequests(t *testing.T) {
+	requests := corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("100m"),
+	}}
+	utilization := autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType}
+	memory := autoscalingv2.MetricSpec{
+		Type:     autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{Name: corev1.ResourceMemory, Target: utilization},
+	}
+
+	// Given
+	lintCtx := testLintContext{
+		testDeployment("app", corev1.Container{Name: "app", Resources: requests}),
+		testDeployment("sidecar",
+			corev1.Container{Name: "app", Resources: requests},
+			corev1.Container{Name: "sidecar"},
+		),
+	}
+
+	// Assert
+	assert.Empty(t, resourceRequests(lintCtx, testHPA("hpa", "app")),
+		"the default CPU utilization target is requested")
+	assert.Equal(t, []string{
+		`container "app" of Deployment "app" has no memory request, ` +
+			`required by the utilization target of the HorizontalPodAutoscaler`,
+	}, messages(resourceRequests(lintCtx, testHPA("hpa", "app", memory))))
+	assert.Equal(t, []string{
+		`container "sidecar" of Deployment "sidecar" has no cpu request, ` +
+			`required by the utilization target of the HorizontalPodAutoscaler`,
+	}, messages(resourceRequests(lintCtx, testHPA("hpa", "sidecar"))))
+	assert.Empty(t, resourceRequests(lintCtx, testHPA("hpa", "missing", memory)),
+		"the HPAs whose workload is not grouped with them are left to dangling-hpa")
+}
@@ -0,0 +1,115 @@
+package hpa
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/extract"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+	"golang.stackrox.io/kube-linter/pkg/templates"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/app-sre/deployment-validation-operator/pkg/utils"
+)
+
+// ResourceRequestsTemplateKey is the key of the template flagging the HPAs scaling on the
+// utilization of a resource the containers of their workload do not request
+const ResourceRequestsTemplateKey = "hpa-target-resource-requests"
+
+func init() {
+	templates.Register(check.Template{
+		HumanName: "HorizontalPodAutoscaler target resource requests",
+		Key:       ResourceRequestsTemplateKey,
+		Description: "Flag HorizontalPodAutoscalers scaling on the utilization of a resource " +
+			"not requested by the containers of their workload",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.HorizontalPodAutoscaler},
+		},
+		ParseAndValidateParams: noParams,
+		Instantiate: func(interface{}) (check.Func, error) {
+			return resourceRequests, nil
+		},
+	})
+}
+
+// utilizationTarget is a resource whose utilization an HPA scales on, for all
+// the containers or only the named one
+type utilizationTarget struct {
+	resource  corev1.ResourceName
+	container string
+}
+
+// utilizationTargets returns the resources whose utilization the given HPA scales on.
+// The utilization is relative to the requests, and cannot be computed without them.
+func utilizationTargets(obj lintcontext.Object) []utilizationTarget {
+	switch hpa := obj.K8sObject.(type) {
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		// the CPU utilization target defaults to 80%
+		return []utilizationTarget{{resource: corev1.ResourceCPU}}
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		if len(hpa.Spec.Metrics) == 0 {
+			// the metrics default to a CPU utilization of 80%
+			return []utilizationTarget{{resource: corev1.ResourceCPU}}
+		}
+		var targets []utilizationTarget
+		for _, metric := range hpa.Spec.Metrics {
+			switch {
+			case metric.Resource != nil &&
+				metric.Resource.Target.Type == autoscalingv2.UtilizationMetricType:
+				targets = append(targets, utilizationTarget{resource: metric.Resource.Name})
+			case metric.ContainerResource != nil &&
+				metric.ContainerResource.Target.Type == autoscalingv2.UtilizationMetricType:
+				targets = append(targets, utilizationTarget{
+					resource:  metric.ContainerResource.Name,
+					container: metric.ContainerResource.Container,
+				})
+			}
+		}
+		return targets
+	default:
+		return nil
+	}
+}
+
+// resourceRequests reports the containers of the workload scaled by the given HPA, found
+// in the lint context, which do not request a resource the HPA scales on the utilization of
+func resourceRequests(lintCtx lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+	targets := utilizationTargets(object)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var diagnostics []diagnostic.Diagnostic
+	for _, workload := range lintCtx.Objects() {
+		if !utils.IsScaleTargetOf(workload.K8sObject, object.K8sObject) {
+			continue
+		}
+		podSpec, ok := extract.PodSpec(workload.K8sObject)
+		if !ok {
+			continue
+		}
+
+		for _, target := range targets {
+			for _, container := range podSpec.Containers {
+				if target.container != "" && target.container != container.Name {
+					continue
+				}
+				if _, ok := container.Resources.Requests[target.resource]; ok {
+					continue
+				}
+				kind := workload.K8sObject.GetObjectKind().GroupVersionKind().Kind
+				diagnostics = append(diagnostics, diagnostic.Diagnostic{
+					Message: fmt.Sprintf("container %q of %s %q has no %s request, "+
+						"required by the utilization target of the HorizontalPodAutoscaler",
+						container.Name, kind, workload.K8sObject.GetName(), target.resource),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,51 @@
+package image
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+	"golang.stackrox.io/kube-linter/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DigestTemplateKey is the key of the template flagging the containers
+// whose image is not pinned by digest
+const DigestTemplateKey = "image-digest"
+
+func init() {
+	templates.Register(check.Template{
+		HumanName:   "Image digest",
+		Key:         DigestTemplateKey,
+		Description: "Flag containers whose image is not pinned by digest",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.DeploymentLike},
+		},
+		Parameters: []check.ParameterDesc{
+			listParam(ExemptRegistriesParam,
+				"Registries, or repositories, whose images do not need to be pinned by digest"),
+		},
+		ParseAndValidateParams: func(params map[string]interface{}) (interface{}, error) {
+			return parseListParams(params, ExemptRegistriesParam)
+		},
+		Instantiate: func(parsed interface{}) (check.Func, error) {
+			return digest(parsed.(map[string][]string)[ExemptRegistriesParam]), nil
+		},
+	})
+}
+
+// digest returns the check of the digest of the images
+func digest(exempt []string) check.Func {
+	return func(_ lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+		return perContainer(object, func(container corev1.Container, ref reference) (string, bool) {
+			if ref.digest != "" || ref.matchesAny(exempt) {
+				return "", false
+			}
+			return fmt.Sprintf("container %q uses the image %q, which is not pinned by digest",
+				container.Name, container.Image), true
+		})
+	}
+}
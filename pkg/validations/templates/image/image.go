@@ -0,0 +1,155 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/extract"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The parameters of the image templates, all of them lists of strings
+const (
+	AllowedRegistriesParam = "allowedRegistries"
+	DeniedRegistriesParam  = "deniedRegistries"
+	ExemptRegistriesParam  = "exemptRegistries"
+	MutableTagsParam       = "mutableTags"
+)
+
+const (
+	defaultRegistry = "docker.io"
+	defaultTag      = "latest"
+)
+
+// defaultMutableTags are the tags considered mutable when the mutableTags parameter is not set
+var defaultMutableTags = []string{defaultTag}
+
+// reference is a parsed image reference
+type reference struct {
+	// name is the normalized name of the image, its registry included, e.g. docker.io/library/nginx
+	name   string
+	tag    string
+	digest string
+}
+
+// parseReference parses the given image reference, normalizing its name as the
+// container runtimes do: the images without registry are pulled from Docker Hub
+func parseReference(image string) reference {
+	var ref reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+
+	domain, _, found := strings.Cut(name, "/")
+	switch {
+	case !found:
+		name = defaultRegistry + "/library/" + name
+	case !strings.ContainsAny(domain, ".:") && domain != "localhost":
+		name = defaultRegistry + "/" + name
+	}
+	ref.name = name
+	return ref
+}
+
+// registry returns the registry of the image
+func (r reference) registry() string {
+	registry, _, _ := strings.Cut(r.name, "/")
+	return registry
+}
+
+// effectiveTag returns the tag of the image, latest when it has neither tag nor digest
+func (r reference) effectiveTag() string {
+	if r.tag == "" && r.digest == "" {
+		return defaultTag
+	}
+	return r.tag
+}
+
+// matchesAny returns true if the image is part of one of the given registries or
+// repositories, e.g. quay.io or quay.io/example
+func (r reference) matchesAny(prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if r.name == prefix || strings.HasPrefix(r.name, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMutableTag returns true if the image is not pinned by digest and its tag is one of the given ones
+func (r reference) hasMutableTag(mutableTags []string) bool {
+	if r.digest != "" {
+		return false
+	}
+	tag := r.effectiveTag()
+	for _, mutable := range mutableTags {
+		if tag == mutable {
+			return true
+		}
+	}
+	return false
+}
+
+// perContainer returns the diagnostics of the given function for every container
+// and init container of the pod spec of the object
+func perContainer(object lintcontext.Object,
+	f func(container corev1.Container, ref reference) (string, bool)) []diagnostic.Diagnostic {
+	podSpec, ok := extract.PodSpec(object.K8sObject)
+	if !ok {
+		return nil
+	}
+
+	var diagnostics []diagnostic.Diagnostic
+	containers := append(append([]corev1.Container(nil), podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range containers {
+		if message, failing := f(container, parseReference(container.Image)); failing {
+			diagnostics = append(diagnostics, diagnostic.Diagnostic{Message: message})
+		}
+	}
+	return diagnostics
+}
+
+// listParam describes a parameter holding a list of strings
+func listParam(name, description string) check.ParameterDesc {
+	return check.ParameterDesc{Name: name, Type: "array", ArrayElemType: "string", Description: description}
+}
+
+// parseListParams returns the given parameters, which must be lists of strings, by name.
+// The parameters not set are nil, while the ones set to an empty list are empty.
+func parseListParams(params map[string]interface{}, names ...string) (map[string][]string, error) {
+	parsed := make(map[string][]string, len(names))
+	for _, name := range names {
+		parsed[name] = nil
+	}
+
+	for name, value := range params {
+		if _, ok := parsed[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		switch v := value.(type) {
+		case nil:
+		case []string:
+			parsed[name] = v
+		case []interface{}:
+			parsed[name] = make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("parameter %q must be a list of strings", name)
+				}
+				parsed[name] = append(parsed[name], s)
+			}
+		default:
+			return nil, fmt.Errorf("parameter %q must be a list of strings", name)
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,154 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testDigest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func testDeployment(initContainers []corev1.Container, containers ...corev1.Container) lintcontext.Object {
+	return lintcontext.Object{K8sObject: &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{InitContainers: initContainers, Containers: containers},
+		}},
+	}}
+}
+
+func testContainer(name, image string) corev1.Container {
+	return corev1.Container{Name: name, Image: image}
+}
+
+func run(f check.Func, object lintcontext.Object) []string {
+	var messages []string
+	for _, d := range f(nil, object) {
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  reference
+	}{
+		{"nginx", reference{name: "docker.io/library/nginx"}},
+		{"nginx:1.27", reference{name: "docker.io/library/nginx", tag: "1.27"}},
+		{"example/app:v1", reference{name: "docker.io/example/app", tag: "v1"}},
+		{"quay.io/example/app@" + testDigest, reference{name: "quay.io/example/app", digest: testDigest}},
+		{"localhost:5000/app:v1@" + testDigest,
+			reference{name: "localhost:5000/app", tag: "v1", digest: testDigest}},
+		{"localhost/app", reference{name: "localhost/app"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseReference(tt.image))
+		})
+	}
+}
+
+func TestRegistries(t *testing.T) {
+	// Given
+	object := testDeployment(
+		[]corev1.Container{testContainer("init", "busybox")},
+		testContainer("app", "quay.io/example/app:v1"),
+		testContainer("proxy", "registry.example.com/proxy:v2"),
+		testContainer("sidecar", "quay.io/other/sidecar:v3"),
+	)
+
+	// Assert
+	assert.Equal(t, []string{
+		`container "init" uses the image "busybox" of the registry docker.io, which is not allowed`,
+		`container "sidecar" uses the image "quay.io/other/sidecar:v3" of the denied registry quay.io`,
+	}, run(registries([]string{"quay.io", "registry.example.com/"}, []string{"quay.io/other"}), object))
+	assert.Equal(t, []string{
+		`container "init" uses the image "busybox" of the denied registry docker.io`,
+	}, run(registries(nil, []string{"docker.io"}), object))
+	assert.Empty(t, run(registries(nil, nil), object), "all the registries are allowed by default")
+	assert.Len(t, run(registries([]string{}, nil), object), 4, "none of the registries is allowed")
+}
+
+func TestDigest(t *testing.T) {
+	// Given
+	object := testDeployment(nil,
+		testContainer("app", "quay.io/example/app@"+testDigest),
+		testContainer("proxy", "registry.example.com/proxy:v2"),
+		testContainer("sidecar", "sidecar:v3"),
+	)
+
+	// Assert
+	assert.Equal(t, []string{
+		`container "sidecar" uses the image "sidecar:v3", which is not pinned by digest`,
+	}, run(digest([]string{"registry.example.com"}), object))
+}
+
+func TestMutableTag(t *testing.T) {
+	// Given
+	object := testDeployment(
+		[]corev1.Container{testContainer("init", "busybox")},
+		testContainer("app", "quay.io/example/app:latest@"+testDigest),
+		testContainer("proxy", "registry.example.com/proxy:main"),
+		testContainer("sidecar", "sidecar:v3"),
+	)
+
+	// Assert
+	assert.Equal(t, []string{
+		`container "init" uses the image "busybox" with the mutable tag "latest"`,
+	}, run(mutableTag(defaultMutableTags), object), "the images pinned by digest are not flagged")
+	assert.Equal(t, []string{
+		`container "proxy" uses the image "registry.example.com/proxy:main" with the mutable tag "main"`,
+	}, run(mutableTag([]string{"main"}), object))
+}
+
+func TestPullPolicy(t *testing.T) {
+	withPolicy := func(c corev1.Container, policy corev1.PullPolicy) corev1.Container {
+		c.ImagePullPolicy = policy
+		return c
+	}
+
+	// Given
+	object := testDeployment(nil,
+		testContainer("implicit-latest", "app"),
+		withPolicy(testContainer("latest", "app:latest"), corev1.PullIfNotPresent),
+		testContainer("main", "app:main"),
+		withPolicy(testContainer("always", "app:main"), corev1.PullAlways),
+		withPolicy(testContainer("pinned", "app:v1"), corev1.PullNever),
+	)
+
+	// Assert
+	assert.Equal(t, []string{
+		`container "latest" uses the image "app:latest" with the mutable tag "latest" ` +
+			`and the IfNotPresent pull policy, and may run a stale image`,
+		`container "main" uses the image "app:main" with the mutable tag "main" ` +
+			`and the IfNotPresent pull policy, and may run a stale image`,
+	}, run(pullPolicy([]string{"latest", "main"}), object))
+}
+
+func TestParseListParams(t *testing.T) {
+	// When
+	parsed, err := parseListParams(map[string]interface{}{
+		AllowedRegistriesParam: []interface{}{"quay.io"},
+		DeniedRegistriesParam:  []string{"docker.io"},
+	}, AllowedRegistriesParam, DeniedRegistriesParam)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		AllowedRegistriesParam: {"quay.io"},
+		DeniedRegistriesParam:  {"docker.io"},
+	}, parsed)
+
+	_, err = parseListParams(map[string]interface{}{MutableTagsParam: "latest"}, MutableTagsParam)
+	assert.Error(t, err)
+	_, err = parseListParams(map[string]interface{}{"unknown": []string{}}, MutableTagsParam)
+	assert.Error(t, err)
+}
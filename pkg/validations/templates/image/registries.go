@@ -0,0 +1,62 @@
+package image
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+	"golang.stackrox.io/kube-linter/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RegistriesTemplateKey is the key of the template flagging the containers
+// whose image is pulled from a registry not allowed
+const RegistriesTemplateKey = "image-registries"
+
+func init() {
+	templates.Register(check.Template{
+		HumanName: "Image registries",
+		Key:       RegistriesTemplateKey,
+		Description: "Flag containers whose image is pulled from a denied registry, " +
+			"or from a registry not allowed",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.DeploymentLike},
+		},
+		Parameters: []check.ParameterDesc{
+			listParam(AllowedRegistriesParam,
+				"Registries, or repositories, the images may be pulled from, all of them when not set"),
+			listParam(DeniedRegistriesParam,
+				"Registries, or repositories, the images may not be pulled from"),
+		},
+		ParseAndValidateParams: func(params map[string]interface{}) (interface{}, error) {
+			return parseListParams(params, AllowedRegistriesParam, DeniedRegistriesParam)
+		},
+		Instantiate: func(parsed interface{}) (check.Func, error) {
+			params := parsed.(map[string][]string)
+			return registries(params[AllowedRegistriesParam], params[DeniedRegistriesParam]), nil
+		},
+	})
+}
+
+// registries returns the check of the registries of the images, the denied
+// registries taking precedence over the allowed ones. All the registries are
+// allowed when allowed is nil, and none of them when it is empty.
+func registries(allowed, denied []string) check.Func {
+	return func(_ lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+		return perContainer(object, func(container corev1.Container, ref reference) (string, bool) {
+			switch {
+			case ref.matchesAny(denied):
+				return fmt.Sprintf("container %q uses the image %q of the denied registry %s",
+					container.Name, container.Image, ref.registry()), true
+			case allowed != nil && !ref.matchesAny(allowed):
+				return fmt.Sprintf("container %q uses the image %q of the registry %s, "+
+					"which is not allowed", container.Name, container.Image, ref.registry()), true
+			default:
+				return "", false
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package image
+
+import (
+	"fmt"
+
+	"golang.stackrox.io/kube-linter/pkg/check"
+	"golang.stackrox.io/kube-linter/pkg/config"
+	"golang.stackrox.io/kube-linter/pkg/diagnostic"
+	"golang.stackrox.io/kube-linter/pkg/lintcontext"
+	"golang.stackrox.io/kube-linter/pkg/objectkinds"
+	"golang.stackrox.io/kube-linter/pkg/templates"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MutableTagTemplateKey is the key of the template flagging the containers
+	// whose image has a mutable tag, such as latest
+	MutableTagTemplateKey = "image-mutable-tag"
+	// PullPolicyTemplateKey is the key of the template flagging the containers whose
+	// image has a mutable tag but is not pulled every time the container starts
+	PullPolicyTemplateKey = "image-pull-policy"
+)
+
+func init() {
+	mutableTagsParam := listParam(MutableTagsParam,
+		"Tags considered mutable, latest when empty. An image without tag nor digest is tagged latest.")
+	parseMutableTags := func(params map[string]interface{}) (interface{}, error) {
+		parsed, err := parseListParams(params, MutableTagsParam)
+		if err != nil {
+			return nil, err
+		}
+		if len(parsed[MutableTagsParam]) == 0 {
+			return defaultMutableTags, nil
+		}
+		return parsed[MutableTagsParam], nil
+	}
+
+	templates.Register(check.Template{
+		HumanName:   "Mutable image tag",
+		Key:         MutableTagTemplateKey,
+		Description: "Flag containers whose image is not pinned by digest and has a mutable tag",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.DeploymentLike},
+		},
+		Parameters:             []check.ParameterDesc{mutableTagsParam},
+		ParseAndValidateParams: parseMutableTags,
+		Instantiate: func(parsed interface{}) (check.Func, error) {
+			return mutableTag(parsed.([]string)), nil
+		},
+	})
+	templates.Register(check.Template{
+		HumanName: "Image pull policy",
+		Key:       PullPolicyTemplateKey,
+		Description: "Flag containers whose image has a mutable tag, but which are not set " +
+			"to pull it every time they start",
+		SupportedObjectKinds: config.ObjectKindsDesc{
+			ObjectKinds: []string{objectkinds.DeploymentLike},
+		},
+		Parameters:             []check.ParameterDesc{mutableTagsParam},
+		ParseAndValidateParams: parseMutableTags,
+		Instantiate: func(parsed interface{}) (check.Func, error) {
+			return pullPolicy(parsed.([]string)), nil
+		},
+	})
+}
+
+// mutableTag returns the check of the tags of the images
+func mutableTag(mutableTags []string) check.Func {
+	return func(_ lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+		return perContainer(object, func(container corev1.Container, ref reference) (string, bool) {
+			if !ref.hasMutableTag(mutableTags) {
+				return "", false
+			}
+			return fmt.Sprintf("container %q uses the image %q with the mutable tag %q",
+				container.Name, container.Image, ref.effectiveTag()), true
+		})
+	}
+}
+
+// pullPolicy returns the check of the pull policy of the images with a mutable tag,
+// which may otherwise run a stale version of the image. The pull policy defaults to
+// Always for the latest tag, and to IfNotPresent for the other ones.
+func pullPolicy(mutableTags []string) check.Func {
+	return func(_ lintcontext.LintContext, object lintcontext.Object) []diagnostic.Diagnostic {
+		return perContainer(object, func(container corev1.Container, ref reference) (string, bool) {
+			if !ref.hasMutableTag(mutableTags) {
+				return "", false
+			}
+			policy := container.ImagePullPolicy
+			if policy == "" {
+				policy = corev1.PullIfNotPresent
+				if ref.effectiveTag() == defaultTag {
+					policy = corev1.PullAlways
+				}
+			}
+			if policy == corev1.PullAlways {
+				return "", false
+			}
+			return fmt.Sprintf("container %q uses the image %q with the mutable tag %q "+
+				"and the %s pull policy, and may run a stale image",
+				container.Name, container.Image, ref.effectiveTag(), policy), true
+		})
+	}
+}
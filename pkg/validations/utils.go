@@ -14,17 +14,30 @@ import (
 )
 
 // GetKubeLinterRegistry returns a CheckRegistry containing kube-linter built-in validations.
-// It initializes a new CheckRegistry, loads the built-in validations into the registry,
-// and returns the resulting registry if successful.
+// It initializes a new CheckRegistry, loads the built-in validations and the DVO ones
+// into the registry, and returns the resulting registry if successful.
 //
 // Returns:
-//   - A CheckRegistry containing kube-linter built-in validations if successful.
-//   - An error if the built-in validations fail to load into the registry.
+//   - A CheckRegistry containing kube-linter built-in and DVO validations if successful.
+//   - An error if the validations fail to load into the registry.
 func GetKubeLinterRegistry() (checkregistry.CheckRegistry, error) {
+	return newKubeLinterRegistry(ImagesConfig{})
+}
+
+// newKubeLinterRegistry returns a CheckRegistry containing the kube-linter built-in
+// validations and the DVO ones, the image checks being parameterized by the given configuration
+func newKubeLinterRegistry(images ImagesConfig) (checkregistry.CheckRegistry, error) {
 	registry := checkregistry.New()
 	if err := builtinchecks.LoadInto(registry); err != nil {
 		return nil, fmt.Errorf("failed to load kube-linter built-in validations: %w", err)
 	}
+	checks := dvoChecks(images)
+	for i := range checks {
+		check := checks[i]
+		if err := registry.Register(&check); err != nil {
+			return nil, fmt.Errorf("failed to load DVO validation %s: %w", check.Name, err)
+		}
+	}
 
 	return registry, nil
 }
@@ -39,10 +52,12 @@ func GetKubeLinterRegistry() (checkregistry.CheckRegistry, error) {
 //   - A slice of strings containing the names of all enabled checks if successful.
 //   - An error if there's an issue while fetching the enabled check names or validating the configuration.
 func GetAllNamesFromRegistry(reg checkregistry.CheckRegistry) ([]string, error) {
-	// Get all checks except for incompatible ones
+	// Get all checks except for incompatible ones, the DVO checks
+	// not being part of the built-in ones
 	cfg := klConfig.Config{
 		Checks: klConfig.ChecksConfig{
 			AddAllBuiltIn: true,
+			Include:       dvoCheckNames(),
 		},
 	}
 
@@ -67,7 +82,7 @@ func newGaugeVecMetric(check klConfig.Check) *prometheus.GaugeVec {
 				"check_description": check.Description,
 				"check_remediation": check.Remediation,
 			},
-		}, []string{"namespace_uid", "namespace", "uid", "name", "kind"})
+		}, baseMetricLabels)
 }
 
 // GetDefaultChecks provides a default set of checks usable in case there is no custom ConfigMap
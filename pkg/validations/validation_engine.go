@@ -3,15 +3,15 @@ package validations
 import (
 	// Used to embed yamls by kube-linter
 
+	"context"
 	_ "embed" // nolint:golint
 	"fmt"
 	"os"
-	"reflect"
 	"regexp"
+	"strconv"
 
 	// Import checks from DVO
 
-	"github.com/app-sre/deployment-validation-operator/pkg/utils"
 	_ "github.com/app-sre/deployment-validation-operator/pkg/validations/all" // nolint:golint
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -49,12 +49,63 @@ type Interface interface {
 	DeleteMetrics(labels prometheus.Labels)
 	// GetEnabledChecks returns the current collection of enabled checks
 	GetEnabledChecks() []string
+	// GetCheck returns the specification of the given enabled check
+	GetCheck(name string) (config.Check, bool)
 	// ResetMetrics resets all the Prometheus Gauge vectors
 	ResetMetrics()
+	// ResetMetricsForChecks resets the Prometheus Gauge vectors of the given checks only
+	ResetMetricsForChecks(checks []string)
 	// SetConfig sets the kubelinter configuration
 	SetConfig(cfg config.Config)
-	// RunValidationsForObjects runs kubelinter validations for provided slice (group) of objects.
-	RunValidationsForObjects(objects []client.Object, namespaceUID string) (ValidationOutcome, error)
+	// SetFailureInfoMetric sets the optional metric exporting the details of the failures
+	SetFailureInfoMetric(m *FailureInfoMetric)
+	// SetFailureTracker sets the optional tracker of the time each check has been failing since
+	SetFailureTracker(t *FailureTracker)
+	// GetResults returns the checks currently failing, as recorded by the failure tracker
+	GetResults() []CheckResult
+	// SetFailureReporter sets an optional receiver of the failures found by RunValidationsForObjects
+	SetFailureReporter(r FailureReporter)
+	// GetMetricLabels returns the object and namespace metadata promoted to metric labels
+	GetMetricLabels() MetricLabelsConfig
+	// SetMetricLabels sets the object and namespace metadata promoted to metric labels.
+	// It must match the labels the metrics have been created with.
+	SetMetricLabels(labels MetricLabelsConfig)
+	// GetReplicas returns how the workloads scaled to zero are validated
+	GetReplicas() ReplicasConfig
+	// SetReplicas sets how the workloads scaled to zero are validated
+	SetReplicas(cfg ReplicasConfig)
+	// GetImages returns the parameters of the image checks
+	GetImages() ImagesConfig
+	// SetImages sets the parameters of the image checks. The registry must be
+	// initialized again for them to be applied.
+	SetImages(cfg ImagesConfig)
+	// GetOwnerResolver returns the resolver of the controllers of the objects, if any
+	GetOwnerResolver() OwnerResolver
+	// SetOwnerResolver sets an optional resolver of the controllers of the objects, the
+	// objects being otherwise attributed to their direct controller only
+	SetOwnerResolver(r OwnerResolver)
+	// GetSourceResolver returns the resolver of the GitOps applications of the objects, if any
+	GetSourceResolver() SourceResolver
+	// SetSourceResolver sets an optional resolver of the GitOps applications of the objects,
+	// their repository being otherwise unknown
+	SetSourceResolver(r SourceResolver)
+	// GetOwnership returns how the objects are attributed to teams
+	GetOwnership() OwnershipConfig
+	// SetOwnership sets how the objects are attributed to teams. The current
+	// configuration is kept if the given one is not valid.
+	SetOwnership(cfg OwnershipConfig) error
+	// GetTeam returns the team owning the given object, empty if it cannot be attributed
+	GetTeam(obj client.Object, namespace Namespace) Team
+	// RunValidationsForObjects runs kubelinter validations for provided slice (group) of objects
+	// and returns the outcome of each object linted, by UID. The objects not linted, e.g. owned by
+	// a workload, are not part of them. The metadata of their namespace is used for the metric
+	// labels promoted from it and to attribute the objects to teams.
+	RunValidationsForObjects(ctx context.Context, objects []client.Object,
+		namespace Namespace) (map[string]ValidationOutcome, error)
+	// EvaluateObjects runs kubelinter validations for provided slice (group) of objects
+	// and returns the failures found without updating any metric. The metadata of their
+	// namespace is used to attribute the objects to teams.
+	EvaluateObjects(ctx context.Context, objects []client.Object, namespace Namespace) ([]Failure, error)
 }
 
 type validationEngine struct {
@@ -63,6 +114,16 @@ type validationEngine struct {
 	enabledChecks    []string
 	registeredChecks map[string]config.Check
 	metrics          map[string]*prometheus.GaugeVec
+	failureInfo      *FailureInfoMetric
+	failureTracker   *FailureTracker
+	failureReporter  FailureReporter
+	metricLabels     MetricLabelsConfig
+	series           *seriesLabels
+	replicas         ReplicasConfig
+	images           ImagesConfig
+	ownerResolver    OwnerResolver
+	sourceResolver   SourceResolver
+	ownership        ownership
 	logger           logr.Logger
 }
 
@@ -82,14 +143,21 @@ func NewValidationEngine(configPath string, metrics map[string]*prometheus.Gauge
 		return nil, err
 	}
 
+	return NewValidationEngineFromConfig(cfg, metrics)
+}
+
+// NewValidationEngineFromConfig creates a new ValidationEngine instance
+// with the provided kube-linter configuration and metrics.
+// A nil metrics map is valid for engines only used through EvaluateObjects.
+func NewValidationEngineFromConfig(cfg config.Config, metrics map[string]*prometheus.GaugeVec) (Interface, error) {
 	ve := &validationEngine{
 		metrics: metrics,
 		config:  cfg,
+		series:  newSeriesLabels(),
 		logger:  ctrl.Log.WithName("validationEngine"),
 	}
 
-	err = ve.InitRegistry()
-	if err != nil {
+	if err := ve.InitRegistry(); err != nil {
 		return nil, err
 	}
 
@@ -119,68 +187,174 @@ func loadConfig(path string) (config.Config, error) {
 }
 
 // RunValidationsForObjects runs validation for the group of related objects
-func (ve *validationEngine) RunValidationsForObjects(objects []client.Object,
-	namespaceUID string) (ValidationOutcome, error) {
+func (ve *validationEngine) RunValidationsForObjects(ctx context.Context, objects []client.Object,
+	namespace Namespace) (map[string]ValidationOutcome, error) {
+	result, info, err := ve.runValidations(ctx, objects, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// The workloads scaled to zero which have not been linted no longer have metrics
+	for _, obj := range info.skipped {
+		req := NewRequestFromObject(obj)
+		req.NamespaceUID = namespace.UID
+		ve.DeleteMetrics(req.ToPromLabels())
+	}
+
+	outcomes, err := ve.processResult(result, namespace, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only once the series of this run are set, delete the ones of the past runs which
+	// are no longer failing, so the series still failing never disappear. The checks no
+	// longer failing are forgotten, the others keep the time they were first seen failing.
+	failing := map[string]map[string]struct{}{}
+	failureInfo := map[string]map[string]struct{}{}
+	for _, report := range result.Reports {
+		uid := string(report.Object.K8sObject.GetUID())
+		if _, ok := failing[uid]; !ok {
+			failing[uid] = map[string]struct{}{}
+			failureInfo[uid] = map[string]struct{}{}
+		}
+		failing[uid][report.Check] = struct{}{}
+		failureInfo[uid][failureInfoKey(report.Check, report.Diagnostic.Message)] = struct{}{}
+	}
+	for _, o := range objects {
+		req := NewRequestFromObject(o)
+		req.NamespaceUID = namespace.UID
+		ve.clearMetrics(failing[req.UID], req.ToPromLabels())
+		ve.failureInfo.retain(req.UID, failureInfo[req.UID])
+		ve.failureTracker.retain(req.UID, failing[req.UID])
+	}
+	return outcomes, nil
+}
+
+// EvaluateObjects runs validation for the group of related objects
+// and returns the failures found, leaving the metrics untouched
+func (ve *validationEngine) EvaluateObjects(ctx context.Context, objects []client.Object,
+	namespace Namespace) ([]Failure, error) {
+	result, info, err := ve.runValidations(ctx, objects, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]Failure, 0, len(result.Reports))
+	for _, report := range result.Reports {
+		failures = append(failures, info.failure(report))
+	}
+	return failures, nil
+}
+
+// groupInfo holds what is known of the objects of a group beyond their own manifest
+type groupInfo struct {
+	// scaledToZero are the UIDs of the workloads scaled to zero
+	scaledToZero map[string]struct{}
+	// skipped are the workloads scaled to zero which have not been linted
+	skipped []client.Object
+	// rootOwners are the top-most controllers of the objects validated, by UID. All
+	// the objects linted are part of it.
+	rootOwners map[string]Owner
+	// teams are the teams owning the objects validated, by UID
+	teams map[string]Team
+	// sources are the GitOps applications the objects validated are deployed from, by UID
+	sources map[string]*Source
+}
+
+// failure converts a kube-linter report into a Failure attributed to the root owner,
+// the team and the GitOps application of the object
+func (info groupInfo) failure(report diagnostic.WithContext) Failure {
+	f := NewFailureFromReport(report)
+	if owner, ok := info.rootOwners[f.UID]; ok {
+		f.RootOwnerKind = owner.Kind
+		f.RootOwnerName = owner.Name
+	}
+	team := info.teams[f.UID]
+	f.Team = team.Name
+	f.Contact = team.Contact
+	f.Source = info.sources[f.UID]
+	return f
+}
+
+// source returns the GitOps application the object is deployed from
+func (ve *validationEngine) source(ctx context.Context, obj client.Object) *Source {
+	if ve.sourceResolver == nil {
+		return TrackedSource(obj, DefaultArgoCDNamespace)
+	}
+	return ve.sourceResolver.Source(ctx, obj)
+}
+
+// owners returns the chain of the controllers of the object
+func (ve *validationEngine) owners(ctx context.Context, obj client.Object) []Owner {
+	if ve.ownerResolver == nil {
+		return directOwners(obj)
+	}
+	return ve.ownerResolver.Owners(ctx, obj)
+}
+
+// runValidations lints the objects of the group which are not owned, directly or not,
+// by a deployment-like object using the currently enabled checks. It also returns the
+// workloads scaled to zero, which are only linted if configured so, the root owners,
+// the teams and the GitOps applications of the objects linted. It has no side effect,
+// the metrics being left to the caller.
+func (ve *validationEngine) runValidations(ctx context.Context, objects []client.Object,
+	namespace Namespace) (run.Result, groupInfo, error) {
+	minReplicas := autoscalerMinReplicas(objects)
+	info := groupInfo{
+		scaledToZero: scaledToZero(objects, minReplicas),
+		rootOwners:   map[string]Owner{},
+		teams:        map[string]Team{},
+		sources:      map[string]*Source{},
+	}
 	lintCtx := &lintContextImpl{}
 	for _, obj := range objects {
-		// Only run checks against an object with no owners.  This should be
-		// the object that controls the configuration
-		if !utils.IsOwner(obj) {
+		// Only run checks against an object with no deployment-like owners.
+		// This should be the object that controls the configuration
+		owners := ve.owners(ctx, obj)
+		if !isTopLevel(obj, owners) {
 			continue
 		}
-		// If controller has no replicas clear do not run any validations
-		if ve.isControllersWithNoReplicas(obj) {
+		// The workloads scaled to zero are not validated, unless configured so
+		if _, ok := info.scaledToZero[string(obj.GetUID())]; ok && !ve.replicas.ValidateScaledToZero {
+			info.skipped = append(info.skipped, obj)
 			continue
 		}
-		lintCtx.addObjects(lintcontext.Object{K8sObject: obj})
+		info.rootOwners[string(obj.GetUID())] = rootOwner(obj, owners)
+		info.teams[string(obj.GetUID())] = ve.ownership.team(obj, namespace)
+		info.sources[string(obj.GetUID())] = ve.source(ctx, obj)
+		lintCtx.addObjects(lintcontext.Object{K8sObject: withAutoscalerReplicas(obj, minReplicas)})
 	}
 	lintCtxs := []lintcontext.LintContext{lintCtx}
 	if len(lintCtxs) == 0 {
-		return ObjectValidationIgnored, nil
+		return run.Result{}, info, nil
 	}
 	result, err := run.Run(lintCtxs, ve.registry, ve.enabledChecks)
 	if err != nil {
 		ve.logger.Error(err, "error running validations")
-		return "", fmt.Errorf("error running validations: %v", err)
+		return run.Result{}, groupInfo{}, fmt.Errorf("error running validations: %v", err)
 	}
-
-	// Clear labels from past run to ensure only results from this run
-	// are reflected in the metrics
-	for _, o := range objects {
-		req := NewRequestFromObject(o)
-		req.NamespaceUID = namespaceUID
-		ve.clearMetrics(result.Reports, req.ToPromLabels())
-	}
-	return ve.processResult(result, namespaceUID)
-}
-
-// isControllersWithNoReplicas checks if the provided object has no replicas
-func (ve *validationEngine) isControllersWithNoReplicas(obj client.Object) bool {
-	objValue := reflect.Indirect(reflect.ValueOf(obj))
-	spec := objValue.FieldByName("Spec")
-	if spec.IsValid() {
-		replicas := spec.FieldByName("Replicas")
-		if replicas.IsValid() {
-			numReplicas, ok := replicas.Interface().(*int32)
-
-			// clear labels if we fail to get a value for numReplicas, or if value is <= 0
-			if !ok || numReplicas == nil || *numReplicas <= 0 {
-				req := NewRequestFromObject(obj)
-				ve.DeleteMetrics(req.ToPromLabels())
-				return true
-			}
-		}
-	}
-	return false
+	return result, info, nil
 }
 
-func (ve *validationEngine) processResult(result run.Result, namespaceUID string) (ValidationOutcome, error) {
-	outcome := ObjectValid
+// processResult updates the metrics from the reports and returns the outcome of each object
+// linted, by UID, from its own reports
+func (ve *validationEngine) processResult(result run.Result, namespace Namespace,
+	info groupInfo) (map[string]ValidationOutcome, error) {
+	outcomes := make(map[string]ValidationOutcome, len(info.rootOwners))
+	for uid := range info.rootOwners {
+		outcomes[uid] = ObjectValid
+	}
+	var failures []Failure
+	// failures by object and check, in the order of the reports
+	tracked := map[trackedKey]*trackedFailures{}
+	// labels of the series of each object failing a check, when labels are promoted
+	series := map[string]prometheus.Labels{}
+	var trackedKeys []trackedKey
 	for _, report := range result.Reports {
 		check, err := ve.getCheckByName(report.Check)
 		if err != nil {
 			ve.logger.Error(err, "Failed to get the check by name", "check", report.Check)
-			return "", fmt.Errorf("error running validations: %v", err)
+			return nil, fmt.Errorf("error running validations: %v", err)
 		}
 
 		metric := ve.getMetric(report.Check)
@@ -191,10 +365,44 @@ func (ve *validationEngine) processResult(result run.Result, namespaceUID string
 			obj := report.Object.K8sObject
 
 			req := NewRequestFromObject(obj)
-			req.NamespaceUID = namespaceUID
+			req.NamespaceUID = namespace.UID
+			req.Labels = ve.metricLabels.values(obj, namespace.Labels)
+			if ve.metricLabels.ScaledToZero {
+				if req.Labels == nil {
+					req.Labels = map[string]string{}
+				}
+				_, scaledToZero := info.scaledToZero[req.UID]
+				req.Labels[scaledToZeroLabel] = strconv.FormatBool(scaledToZero)
+			}
+			if ve.metricLabels.RootOwner {
+				if req.Labels == nil {
+					req.Labels = map[string]string{}
+				}
+				owner := info.rootOwners[req.UID]
+				req.Labels[rootOwnerKindLabel] = owner.Kind
+				req.Labels[rootOwnerNameLabel] = owner.Name
+			}
+			if ve.metricLabels.Team {
+				if req.Labels == nil {
+					req.Labels = map[string]string{}
+				}
+				req.Labels[teamLabel] = info.teams[req.UID].Name
+			}
+			if len(req.Labels) > 0 {
+				series[req.UID] = req.ToPromLabels()
+			}
 			metric.With(req.ToPromLabels()).Set(1)
+			ve.failureInfo.set(req, report.Check, report.Diagnostic.Message)
+			failure := info.failure(report)
+			key := trackedKey{uid: req.UID, check: report.Check}
+			if _, ok := tracked[key]; !ok {
+				tracked[key] = &trackedFailures{req: req}
+				trackedKeys = append(trackedKeys, key)
+			}
+			tracked[key].failures = append(tracked[key].failures, failure)
+			failures = append(failures, failure)
 
-			outcome = ObjectNeedsImprovement
+			outcomes[req.UID] = ObjectNeedsImprovement
 
 			ve.logger.WithValues(
 				"namespace", obj.GetNamespace(),
@@ -207,11 +415,35 @@ func (ve *validationEngine) processResult(result run.Result, namespaceUID string
 			).V(1).Info("New Metric has been created")
 		}
 	}
-	return outcome, nil
+
+	// the promoted metadata may have changed since the previous run
+	for uid, labels := range series {
+		if previous, changed := ve.series.set(uid, labels); changed {
+			for _, vector := range ve.metrics {
+				vector.Delete(previous)
+			}
+		}
+	}
+	for _, key := range trackedKeys {
+		ve.failureTracker.set(tracked[key].req, key.check, tracked[key].failures)
+	}
+	if ve.failureReporter != nil && len(failures) > 0 {
+		ve.failureReporter.ReportFailures(failures)
+	}
+	return outcomes, nil
+}
+
+type trackedKey struct {
+	uid, check string
+}
+
+type trackedFailures struct {
+	req      Request
+	failures []Failure
 }
 
 func (ve *validationEngine) InitRegistry() error {
-	registry, err := GetKubeLinterRegistry()
+	registry, err := newKubeLinterRegistry(ve.images)
 	if err != nil {
 		return err
 	}
@@ -251,24 +483,25 @@ func (ve *validationEngine) getMetric(name string) *prometheus.GaugeVec {
 	return m
 }
 
+// DeleteMetrics deletes the series of the object identified by the given labels, whatever
+// the values of the labels promoted from the object and namespace metadata
 func (ve *validationEngine) DeleteMetrics(labels prometheus.Labels) {
+	series := ve.series.of(labels)
 	for _, vector := range ve.metrics {
-		vector.Delete(labels)
+		vector.Delete(series)
 	}
+	ve.series.delete(labels["uid"])
+	ve.failureInfo.deleteObject(labels["uid"])
+	ve.failureTracker.deleteObject(labels["uid"])
 }
 
-func (ve *validationEngine) clearMetrics(reports []diagnostic.WithContext, labels prometheus.Labels) {
-	// Create a list of validation names for use to delete the labels from any
-	// metric which isn't in the report but for which there is a metric
-	reportValidationNames := map[string]struct{}{}
-	for _, report := range reports {
-		reportValidationNames[report.Check] = struct{}{}
-	}
-
-	// Delete the labels for validations that aren't in the list of reports
-	for metricValidationName := range ve.metrics {
-		if _, ok := reportValidationNames[metricValidationName]; !ok {
-			ve.metrics[metricValidationName].Delete(labels)
+// clearMetrics deletes the series of the object identified by the given labels
+// of the checks which are not part of the given failing ones
+func (ve *validationEngine) clearMetrics(failing map[string]struct{}, labels prometheus.Labels) {
+	series := ve.series.of(labels)
+	for check, metric := range ve.metrics {
+		if _, ok := failing[check]; !ok {
+			metric.Delete(series)
 		}
 	}
 }
@@ -277,6 +510,19 @@ func (ve *validationEngine) ResetMetrics() {
 	for _, metric := range ve.metrics {
 		metric.Reset()
 	}
+	ve.series.reset()
+	ve.failureInfo.reset()
+	ve.failureTracker.reset()
+}
+
+func (ve *validationEngine) ResetMetricsForChecks(checks []string) {
+	for _, check := range checks {
+		if metric := ve.getMetric(check); metric != nil {
+			metric.Reset()
+		}
+	}
+	ve.failureInfo.deleteChecks(checks)
+	ve.failureTracker.deleteChecks(checks)
 }
 
 // GetEnabledChecks returns the current collection of enabled checks
@@ -284,6 +530,11 @@ func (ve validationEngine) GetEnabledChecks() []string {
 	return ve.enabledChecks
 }
 
+func (ve *validationEngine) GetCheck(name string) (config.Check, bool) {
+	check, ok := ve.registeredChecks[name]
+	return check, ok
+}
+
 func (ve *validationEngine) getCheckByName(name string) (config.Check, error) {
 	check, ok := ve.registeredChecks[name]
 	if !ok {
@@ -321,6 +572,79 @@ func (ve *validationEngine) SetConfig(cfg config.Config) {
 	ve.config = cfg
 }
 
+func (ve *validationEngine) SetFailureInfoMetric(m *FailureInfoMetric) {
+	ve.failureInfo = m
+}
+
+func (ve *validationEngine) SetFailureTracker(t *FailureTracker) {
+	ve.failureTracker = t
+}
+
+func (ve *validationEngine) GetResults() []CheckResult {
+	return ve.failureTracker.Results()
+}
+
+func (ve *validationEngine) SetFailureReporter(r FailureReporter) {
+	ve.failureReporter = r
+}
+
+func (ve *validationEngine) GetMetricLabels() MetricLabelsConfig {
+	return ve.metricLabels
+}
+
+func (ve *validationEngine) SetMetricLabels(labels MetricLabelsConfig) {
+	ve.metricLabels = labels
+}
+
+func (ve *validationEngine) GetReplicas() ReplicasConfig {
+	return ve.replicas
+}
+
+func (ve *validationEngine) SetReplicas(cfg ReplicasConfig) {
+	ve.replicas = cfg
+}
+
+func (ve *validationEngine) GetImages() ImagesConfig {
+	return ve.images
+}
+
+func (ve *validationEngine) SetImages(cfg ImagesConfig) {
+	ve.images = cfg
+}
+
+func (ve *validationEngine) GetOwnerResolver() OwnerResolver {
+	return ve.ownerResolver
+}
+
+func (ve *validationEngine) SetOwnerResolver(r OwnerResolver) {
+	ve.ownerResolver = r
+}
+
+func (ve *validationEngine) GetSourceResolver() SourceResolver {
+	return ve.sourceResolver
+}
+
+func (ve *validationEngine) SetSourceResolver(r SourceResolver) {
+	ve.sourceResolver = r
+}
+
+func (ve *validationEngine) GetOwnership() OwnershipConfig {
+	return ve.ownership.cfg
+}
+
+func (ve *validationEngine) SetOwnership(cfg OwnershipConfig) error {
+	o, err := newOwnership(cfg)
+	if err != nil {
+		return fmt.Errorf("parsing ownership: %w", err)
+	}
+	ve.ownership = o
+	return nil
+}
+
+func (ve *validationEngine) GetTeam(obj client.Object, namespace Namespace) Team {
+	return ve.ownership.team(obj, namespace)
+}
+
 // removeCheckFromConfig function searches for the given check name in both the "Include" and "Exclude" lists
 // of checks in the ValidationEngine's configuration. If the check is found in either list, it is removed by updating
 // the respective list.
@@ -1,7 +1,9 @@
 package validations
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"testing"
 
 	"github.com/app-sre/deployment-validation-operator/pkg/testutils"
@@ -237,7 +239,8 @@ func TestRunValidationsForObjects(t *testing.T) {
 			request.NamespaceUID = testNamespaceUID
 
 			// run validations with "broken" (replica=1) deployment object
-			_, err = ve.RunValidationsForObjects([]client.Object{deployment}, request.NamespaceUID)
+			_, err = ve.RunValidationsForObjects(context.Background(),
+				[]client.Object{deployment}, Namespace{UID: request.NamespaceUID})
 			assert.NoError(t, err, "Error running validations")
 
 			labels := request.ToPromLabels()
@@ -260,7 +263,8 @@ func TestRunValidationsForObjects(t *testing.T) {
 
 			// Problem resolved
 			deployment.Spec.Replicas = &tt.updatedReplicaCount
-			_, err = ve.RunValidationsForObjects([]client.Object{deployment}, request.NamespaceUID)
+			_, err = ve.RunValidationsForObjects(context.Background(),
+				[]client.Object{deployment}, Namespace{UID: request.NamespaceUID})
 			assert.NoError(t, err, "Error running validations")
 			// Metric with label combination should be successfully cleared because problem was resolved.
 			// The 'GetMetricWith()' function will create a new metric with provided labels if it
@@ -274,7 +278,8 @@ func TestRunValidationsForObjects(t *testing.T) {
 
 			if tt.runAdditionalValidation {
 				deployment.Spec.Replicas = &tt.initialReplicaCount
-				_, err = ve.RunValidationsForObjects([]client.Object{deployment}, request.NamespaceUID)
+				_, err = ve.RunValidationsForObjects(context.Background(),
+					[]client.Object{deployment}, Namespace{UID: request.NamespaceUID})
 				assert.NoError(t, err, "Error running validations")
 
 				customCheckMetricVal, err := getMetricValue(ve, customCheckName, labels)
@@ -299,7 +304,8 @@ func TestRunValidationsForObjectsAndResetMetrics(t *testing.T) {
 	request.NamespaceUID = testNamespaceUID
 
 	// run validations with "broken" (replica=1) deployment object
-	_, err = ve.RunValidationsForObjects([]client.Object{deployment}, request.NamespaceUID)
+	_, err = ve.RunValidationsForObjects(context.Background(),
+		[]client.Object{deployment}, Namespace{UID: request.NamespaceUID})
 	assert.NoError(t, err, "Error running validations")
 
 	labels := request.ToPromLabels()
@@ -330,6 +336,97 @@ func TestRunValidationsForObjectsAndResetMetrics(t *testing.T) {
 		customCheckName, customCheckMetricVal, 0)
 }
 
+func TestRunValidationsForObjectsClearsStaleSeries(t *testing.T) {
+	// Given
+	metrics := make(map[string]*prometheus.GaugeVec)
+	ve, err := newValidationEngine("test-resources/config-with-custom-check.yaml", metrics)
+	assert.NoError(t, err, "Error creating a new validation engine")
+	failing, err := createTestDeployment(testutils.TemplateArgs{Replicas: 1})
+	assert.NoError(t, err)
+	fixed, err := createTestDeployment(testutils.TemplateArgs{Replicas: 3})
+	assert.NoError(t, err)
+	fixed.Name, fixed.UID = "fixed", "fixed-uid"
+	failingLabels := NewRequestFromObject(failing)
+	failingLabels.NamespaceUID = testNamespaceUID
+	fixedLabels := NewRequestFromObject(fixed)
+	fixedLabels.NamespaceUID = testNamespaceUID
+	// the series of a past run, when the fixed deployment was failing too
+	ve.getMetric(customCheckName).With(fixedLabels.ToPromLabels()).Set(1)
+
+	// When
+	_, err = ve.RunValidationsForObjects(context.Background(),
+		[]client.Object{failing, fixed}, Namespace{UID: testNamespaceUID})
+
+	// Assert
+	assert.NoError(t, err)
+	value, err := getMetricValue(ve, customCheckName, failingLabels.ToPromLabels())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+	value, err = getMetricValue(ve, customCheckName, fixedLabels.ToPromLabels())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, value, "the check failing for another object of the group is cleared")
+}
+
+func TestResetMetricsForChecks(t *testing.T) {
+	labels := prometheus.Labels{
+		"namespace_uid": testNamespaceUID,
+		"namespace":     "test",
+		"uid":           "uid",
+		"name":          "test",
+		"kind":          "Deployment",
+	}
+	ve := validationEngine{
+		metrics: map[string]*prometheus.GaugeVec{
+			"host-pid": newGaugeVecMetric(config.Check{Name: "host-pid"}),
+			"host-ipc": newGaugeVecMetric(config.Check{Name: "host-ipc"}),
+		},
+	}
+	ve.metrics["host-pid"].With(labels).Set(1)
+	ve.metrics["host-ipc"].With(labels).Set(1)
+
+	ve.ResetMetricsForChecks([]string{"host-pid", "not-a-metric"})
+
+	assert.Equal(t, 0, promUtils.CollectAndCount(ve.metrics["host-pid"]))
+	assert.Equal(t, 1, promUtils.CollectAndCount(ve.metrics["host-ipc"]))
+}
+
+func TestDeleteMetrics(t *testing.T) {
+	// Given
+	identity := prometheus.Labels{
+		"namespace_uid": testNamespaceUID,
+		"namespace":     "test",
+		"uid":           "uid",
+		"name":          "test",
+		"kind":          "Deployment",
+	}
+	labels := maps.Clone(identity)
+	labels["team"] = "payments"
+	other := maps.Clone(labels)
+	other["uid"] = "other-uid"
+	newMetric := func() *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "check"},
+			MetricLabelNames(MetricLabelsConfig{Team: true}))
+	}
+	ve := validationEngine{
+		metrics: map[string]*prometheus.GaugeVec{"host-pid": newMetric(), "host-ipc": newMetric()},
+		series:  newSeriesLabels(),
+	}
+	for _, metric := range ve.metrics {
+		metric.With(labels).Set(1)
+		metric.With(other).Set(1)
+	}
+	ve.series.set("uid", labels)
+	ve.series.set("other-uid", other)
+
+	// When
+	ve.DeleteMetrics(identity)
+
+	// Assert
+	assert.Equal(t, 1, promUtils.CollectAndCount(ve.metrics["host-pid"]))
+	assert.Equal(t, 1, promUtils.CollectAndCount(ve.metrics["host-ipc"]))
+	assert.Equal(t, identity, ve.series.of(identity))
+}
+
 func getMetricValue(v *validationEngine, checkName string, labels prometheus.Labels) (int, error) {
 	gauge := v.getMetric(checkName)
 	if gauge == nil {
@@ -353,7 +450,8 @@ func TestExcludedChecksAreNotActive(t *testing.T) {
 	request := NewRequestFromObject(deployment)
 	request.NamespaceUID = testNamespaceUID
 
-	_, err = ve.RunValidationsForObjects([]client.Object{deployment}, request.NamespaceUID)
+	_, err = ve.RunValidationsForObjects(context.Background(),
+		[]client.Object{deployment}, Namespace{UID: request.NamespaceUID})
 	assert.NoError(t, err)
 
 	// following two checks are excluded in the corresponding config file